@@ -0,0 +1,227 @@
+package conn
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// batchTimeout bounds a batch request of calls without deadline, others end with the earliest deadline of the calls
+const batchTimeout = 30 * time.Second
+
+// BatchError is the error of the whole batch request, returned to all its calls
+type BatchError struct {
+	Err     error
+	counted int32
+}
+
+func (e *BatchError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// CountFailure reports if err is to be counted as a failure of the node. Failed batch request is one
+// failure however many calls it carried, so it is counted only for the first of its calls asking.
+func CountFailure(err error) bool {
+	var be *BatchError
+	if errors.As(err, &be) {
+		return atomic.CompareAndSwapInt32(&be.counted, 0, 1)
+	}
+	return true
+}
+
+type batchCall struct {
+	ctx  context.Context
+	elem rpc.BatchElem
+	done chan struct{}
+}
+
+// Batcher collects concurrent eth_calls into JSON-RPC batch requests. Batch is sent when it reaches
+// max size or linger time after its first call, whichever comes first. Calls cancelled before
+// their batch is sent are left out of it.
+type Batcher struct {
+	c       *rpc.Client
+	maxSize int
+	linger  time.Duration
+
+	// Limiter is charged once per batch request, calls made through the batcher must not be charged again
+	Limiter *Limiter
+
+	l       sync.Mutex
+	pending []*batchCall
+	timer   *time.Timer
+}
+
+// NewBatcher is Batcher constructor
+func NewBatcher(c *rpc.Client, maxSize int, linger time.Duration) *Batcher {
+	return &Batcher{c: c, maxSize: maxSize, linger: linger}
+}
+
+// CallContract executes eth_call at given block as a part of a batch, nil block number means the latest block
+func (b *Batcher) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	blockArg := "latest"
+	if blockNumber != nil {
+		blockArg = hexutil.EncodeBig(blockNumber)
+	}
+	var hex hexutil.Bytes
+	if err := b.CallContext(ctx, &hex, "eth_call", callArg(msg), blockArg); err != nil {
+		return nil, err
+	}
+	return hex, nil
+}
+
+// PendingCallContract executes eth_call in the pending state as a part of a batch
+func (b *Batcher) PendingCallContract(ctx context.Context, msg ethereum.CallMsg) ([]byte, error) {
+	var hex hexutil.Bytes
+	if err := b.CallContext(ctx, &hex, "eth_call", callArg(msg), "pending"); err != nil {
+		return nil, err
+	}
+	return hex, nil
+}
+
+// CallContext queues the call and waits until its batch is answered. Result is decoded the same way
+// rpc.Client.CallContext does, error of the whole batch request is returned to all its calls.
+func (b *Batcher) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	bc := &batchCall{
+		ctx:  ctx,
+		elem: rpc.BatchElem{Method: method, Args: args, Result: result},
+		done: make(chan struct{}),
+	}
+
+	b.l.Lock()
+	b.pending = append(b.pending, bc)
+	if len(b.pending) >= b.maxSize {
+		batch := b.take()
+		b.l.Unlock()
+		go b.send(batch)
+	} else {
+		if len(b.pending) == 1 {
+			b.timer = time.AfterFunc(b.linger, b.flush)
+		}
+		b.l.Unlock()
+	}
+
+	select {
+	case <-bc.done:
+		return bc.elem.Error
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// take has to be called under the lock
+func (b *Batcher) take() []*batchCall {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	batch := b.pending
+	b.pending = nil
+	return batch
+}
+
+func (b *Batcher) flush() {
+	b.l.Lock()
+	batch := b.take()
+	b.l.Unlock()
+
+	if len(batch) > 0 {
+		b.send(batch)
+	}
+}
+
+func (b *Batcher) send(batch []*batchCall) {
+	if batch = live(batch); len(batch) == 0 {
+		return
+	}
+	ctx, cancel := context.WithDeadline(context.Background(), deadline(batch))
+	defer cancel()
+
+	if err := b.Limiter.Wait(ctx); err != nil {
+		finish(batch, nil, err)
+		return
+	}
+	// calls may have been cancelled while waiting for the limit
+	if batch = live(batch); len(batch) == 0 {
+		return
+	}
+
+	elems := make([]rpc.BatchElem, len(batch))
+	for i, bc := range batch {
+		elems[i] = bc.elem
+	}
+
+	var err error
+	if berr := b.c.BatchCallContext(ctx, elems); berr != nil {
+		err = &BatchError{Err: berr}
+	}
+	finish(batch, elems, err)
+}
+
+// deadline is the earliest deadline of the calls, batchTimeout from now at the latest
+func deadline(batch []*batchCall) time.Time {
+	d := time.Now().Add(batchTimeout)
+	for _, bc := range batch {
+		if cd, ok := bc.ctx.Deadline(); ok && cd.Before(d) {
+			d = cd
+		}
+	}
+	return d
+}
+
+// live finishes cancelled calls and returns the others
+func live(batch []*batchCall) []*batchCall {
+	calls := batch[:0]
+	for _, bc := range batch {
+		if err := bc.ctx.Err(); err != nil {
+			bc.elem.Error = err
+			close(bc.done)
+			continue
+		}
+		calls = append(calls, bc)
+	}
+	return calls
+}
+
+// finish sets errors of the answered elements, error of the whole batch request is returned to all its calls
+func finish(batch []*batchCall, elems []rpc.BatchElem, err error) {
+	for i, bc := range batch {
+		if err != nil {
+			bc.elem.Error = err
+		} else {
+			bc.elem.Error = elems[i].Error
+		}
+		close(bc.done)
+	}
+}
+
+// callArg encodes call message the same way ethclient does
+func callArg(msg ethereum.CallMsg) interface{} {
+	arg := map[string]interface{}{
+		"from": msg.From,
+		"to":   msg.To,
+	}
+	if len(msg.Data) > 0 {
+		arg["data"] = hexutil.Bytes(msg.Data)
+	}
+	if msg.Value != nil {
+		arg["value"] = (*hexutil.Big)(msg.Value)
+	}
+	if msg.Gas != 0 {
+		arg["gas"] = hexutil.Uint64(msg.Gas)
+	}
+	if msg.GasPrice != nil {
+		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
+	}
+	return arg
+}
@@ -0,0 +1,286 @@
+package conn
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// revertError is returned by reverted calls
+type revertError struct{}
+
+func (revertError) Error() string  { return "execution reverted" }
+func (revertError) ErrorCode() int { return 3 }
+
+// callService answers eth_call with its data, data 0xdead reverts
+type callService struct{}
+
+func (callService) Call(args map[string]interface{}, block string) (hexutil.Bytes, error) {
+	data, _ := args["data"].(string)
+	if data == "0xdead" {
+		return nil, revertError{}
+	}
+	return hexutil.Decode(data)
+}
+
+// batchServer records sizes of the batch requests it receives
+type batchServer struct {
+	l       sync.Mutex
+	batches []int
+	fail    bool
+	delay   time.Duration
+}
+
+func (bs *batchServer) sizes() []int {
+	bs.l.Lock()
+	defer bs.l.Unlock()
+	return append([]int(nil), bs.batches...)
+}
+
+func newBatchServer(t *testing.T) (*batchServer, *rpc.Client) {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("eth", callService{}); err != nil {
+		t.Fatal(err)
+	}
+	bs := &batchServer{}
+	hs := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		var batch []json.RawMessage
+		if json.Unmarshal(body, &batch) == nil {
+			bs.l.Lock()
+			bs.batches = append(bs.batches, len(batch))
+			fail, delay := bs.fail, bs.delay
+			bs.l.Unlock()
+			select {
+			case <-time.After(delay):
+			case <-req.Context().Done():
+				return
+			}
+			if fail {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		srv.ServeHTTP(w, req)
+	}))
+	c, err := rpc.DialHTTP(hs.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		c.Close()
+		hs.Close()
+		srv.Stop()
+	})
+	return bs, c
+}
+
+func callMsg(data string) ethereum.CallMsg {
+	to := common.HexToAddress("0xaa")
+	return ethereum.CallMsg{To: &to, Data: hexutil.MustDecode(data)}
+}
+
+func TestBatcher(t *testing.T) {
+	tests := []struct {
+		name    string
+		maxSize int
+		linger  time.Duration
+		calls   []string
+		fail    bool
+		batches []int
+		errs    []bool
+		// failures is the number of the calls counting their error as node failure
+		failures int
+	}{
+		{
+			name:    "flush on size",
+			maxSize: 3,
+			linger:  time.Hour,
+			calls:   []string{"0x01", "0x02", "0x03"},
+			batches: []int{3},
+			errs:    []bool{false, false, false},
+		}, {
+			name:    "flush on linger",
+			maxSize: 10,
+			linger:  50 * time.Millisecond,
+			calls:   []string{"0x01", "0x02"},
+			batches: []int{2},
+			errs:    []bool{false, false},
+		}, {
+			name:     "per element errors",
+			maxSize:  3,
+			linger:   time.Hour,
+			calls:    []string{"0x01", "0xdead", "0x03"},
+			batches:  []int{3},
+			errs:     []bool{false, true, false},
+			failures: 1,
+		}, {
+			name:     "batch request error",
+			maxSize:  2,
+			linger:   time.Hour,
+			calls:    []string{"0x01", "0x02"},
+			fail:     true,
+			batches:  []int{2},
+			errs:     []bool{true, true},
+			failures: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs, c := newBatchServer(t)
+			bs.fail = tt.fail
+			b := NewBatcher(c, tt.maxSize, tt.linger)
+
+			res := make([][]byte, len(tt.calls))
+			errs := make([]error, len(tt.calls))
+			var wg sync.WaitGroup
+			for i, data := range tt.calls {
+				wg.Add(1)
+				go func(i int, data string) {
+					defer wg.Done()
+					res[i], errs[i] = b.CallContract(context.Background(), callMsg(data), nil)
+				}(i, data)
+			}
+			wg.Wait()
+
+			failures := 0
+			for _, err := range errs {
+				if err != nil && CountFailure(err) {
+					failures++
+				}
+			}
+			if failures != tt.failures {
+				t.Errorf("failures = %d, want %d", failures, tt.failures)
+			}
+			for i, data := range tt.calls {
+				if (errs[i] != nil) != tt.errs[i] {
+					t.Errorf("call %d error = %v, want error %v", i, errs[i], tt.errs[i])
+					continue
+				}
+				if errs[i] == nil && hexutil.Encode(res[i]) != data {
+					t.Errorf("call %d = %x, want %s", i, res[i], data)
+				}
+				if data == "0xdead" && !IsContractError(errs[i]) {
+					t.Errorf("call %d error = %v, want contract error", i, errs[i])
+				}
+			}
+			if got := bs.sizes(); len(got) != len(tt.batches) || got[0] != tt.batches[0] {
+				t.Errorf("batches = %v, want %v", got, tt.batches)
+			}
+		})
+	}
+}
+
+func TestBatcherCancelled(t *testing.T) {
+	bs, c := newBatchServer(t)
+	b := NewBatcher(c, 10, 100*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelledErr := make(chan error, 1)
+	go func() {
+		_, err := b.CallContract(ctx, callMsg("0x01"), nil)
+		cancelledErr <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	if _, err := b.CallContract(context.Background(), callMsg("0x02"), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := <-cancelledErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled call error = %v, want %v", err, context.Canceled)
+	}
+	if got := bs.sizes(); len(got) != 1 || got[0] != 1 {
+		t.Errorf("batches = %v, want one of the call not cancelled", got)
+	}
+
+	// batch of cancelled calls only is not sent
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := b.CallContract(ctx, callMsg("0x03"), nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	time.Sleep(150 * time.Millisecond)
+	if got := bs.sizes(); len(got) != 1 {
+		t.Errorf("batches = %v, want cancelled batch not sent", got)
+	}
+}
+
+func TestBatcherDeadline(t *testing.T) {
+	bs, c := newBatchServer(t)
+	bs.delay = time.Minute
+	b := NewBatcher(c, 2, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i, ctx := range []context.Context{ctx, context.Background()} {
+		wg.Add(1)
+		go func(i int, ctx context.Context) {
+			defer wg.Done()
+			_, errs[i] = b.CallContract(ctx, callMsg("0x01"), nil)
+		}(i, ctx)
+	}
+	wg.Wait()
+
+	// the batch request ends with the earliest deadline of its calls rather than batchTimeout
+	if d := time.Since(start); d > batchTimeout/2 {
+		t.Errorf("batch answered after %v", d)
+	}
+	for i, err := range errs {
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("call %d error = %v, want %v", i, err, context.DeadlineExceeded)
+		}
+	}
+}
+
+func TestBatcherLimiter(t *testing.T) {
+	bs, c := newBatchServer(t)
+	b := NewBatcher(c, 3, time.Hour)
+	// a single token, next one comes in hours
+	b.Limiter = NewLimiter("http://node", 0.0001, 1, 10*time.Millisecond)
+
+	batch := func() []error {
+		errs := make([]error, 3)
+		var wg sync.WaitGroup
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, errs[i] = b.CallContract(context.Background(), callMsg("0x01"), nil)
+			}(i)
+		}
+		wg.Wait()
+		return errs
+	}
+
+	for i, err := range batch() {
+		if err != nil {
+			t.Errorf("call %d of the first batch failed: %v", i, err)
+		}
+	}
+	for i, err := range batch() {
+		if !errors.Is(err, ErrQuotaExhausted) {
+			t.Errorf("call %d of the second batch error = %v, want %v", i, err, ErrQuotaExhausted)
+		}
+	}
+	if got := bs.sizes(); len(got) != 1 || got[0] != 3 {
+		t.Errorf("batches = %v, want only the first one sent", got)
+	}
+}
@@ -0,0 +1,93 @@
+package conn
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without calling the node while its circuit breaker is open
+var ErrCircuitOpen = errors.New("ethereum node circuit breaker is open")
+
+// Breaker states
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// Breaker is a circuit breaker of a single node. It opens after Threshold consecutive failures
+// and lets one trial call through every Cooldown, the first success closes it again.
+type Breaker struct {
+	threshold uint64
+	cooldown  time.Duration
+
+	l        sync.Mutex
+	failures uint64
+	openedAt time.Time
+}
+
+// NewBreaker is Breaker constructor, zero threshold never opens the breaker
+func NewBreaker(threshold uint64, cooldown time.Duration) *Breaker {
+	return &Breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// Allow reports if the node may be called. Once the cooldown of open breaker passes,
+// it allows a single trial call and starts the cooldown again.
+func (b *Breaker) Allow() bool {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	if !b.tripped() {
+		return true
+	}
+	if time.Since(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.openedAt = time.Now()
+	return true
+}
+
+// Success closes the breaker
+func (b *Breaker) Success() {
+	b.l.Lock()
+	defer b.l.Unlock()
+	b.failures = 0
+}
+
+// Failure counts consecutive failures, breaker (re)opens when they reach the threshold
+func (b *Breaker) Failure() {
+	b.l.Lock()
+	defer b.l.Unlock()
+	b.failures++
+	if b.tripped() {
+		b.openedAt = time.Now()
+	}
+}
+
+// Failures returns the number of consecutive failures
+func (b *Breaker) Failures() uint64 {
+	b.l.Lock()
+	defer b.l.Unlock()
+	return b.failures
+}
+
+// State returns the breaker state without taking the trial call
+func (b *Breaker) State() string {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	switch {
+	case !b.tripped():
+		return BreakerClosed
+	case time.Since(b.openedAt) < b.cooldown:
+		return BreakerOpen
+	default:
+		return BreakerHalfOpen
+	}
+}
+
+// tripped has to be called under the lock
+func (b *Breaker) tripped() bool {
+	return b.threshold > 0 && b.failures >= b.threshold
+}
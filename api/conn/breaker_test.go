@@ -0,0 +1,83 @@
+package conn
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	type step struct {
+		failures int
+		success  bool
+		wait     time.Duration
+		state    string
+		allow    bool
+	}
+	tests := []struct {
+		name      string
+		threshold uint64
+		steps     []step
+	}{
+		{
+			name:      "opens after threshold",
+			threshold: 3,
+			steps: []step{
+				{failures: 2, state: BreakerClosed, allow: true},
+				{failures: 1, state: BreakerOpen, allow: false},
+			},
+		}, {
+			name:      "success resets failures",
+			threshold: 3,
+			steps: []step{
+				{failures: 2, success: true, state: BreakerClosed, allow: true},
+				{failures: 2, state: BreakerClosed, allow: true},
+			},
+		}, {
+			name:      "half-open trial succeeds",
+			threshold: 1,
+			steps: []step{
+				{failures: 1, state: BreakerOpen, allow: false},
+				{wait: 30 * time.Millisecond, state: BreakerHalfOpen, allow: true},
+				{state: BreakerOpen, allow: false},
+				{success: true, state: BreakerClosed, allow: true},
+			},
+		}, {
+			name:      "half-open trial fails",
+			threshold: 1,
+			steps: []step{
+				{failures: 1, state: BreakerOpen, allow: false},
+				{wait: 30 * time.Millisecond, state: BreakerHalfOpen, allow: true},
+				{failures: 1, state: BreakerOpen, allow: false},
+				{wait: 30 * time.Millisecond, state: BreakerHalfOpen, allow: true},
+			},
+		}, {
+			name:      "zero threshold never opens",
+			threshold: 0,
+			steps: []step{
+				{failures: 100, state: BreakerClosed, allow: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBreaker(tt.threshold, 20*time.Millisecond)
+			for i, s := range tt.steps {
+				for j := 0; j < s.failures; j++ {
+					b.Failure()
+				}
+				if s.success {
+					b.Success()
+				}
+				time.Sleep(s.wait)
+
+				if state := b.State(); state != s.state {
+					t.Errorf("step %d: state = %s, want %s", i, state, s.state)
+				}
+				if allow := b.Allow(); allow != s.allow {
+					t.Errorf("step %d: allow = %v, want %v", i, allow, s.allow)
+				}
+			}
+		})
+	}
+}
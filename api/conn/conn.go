@@ -3,20 +3,132 @@ package conn
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math/big"
+	"strings"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/figment-networks/ethereum-worker/structures"
 )
 
 var ErrEmptyResponse = errors.New("Returned Empty Response (Reverted 0x)")
 
+var ErrUnresolvedBlock = errors.New("block tag has to be resolved to the block number")
+
+// CallOpts returns call options for given block. Safe and finalized tags cannot be expressed as call options,
+// so they have to be resolved to the block number first.
+func CallOpts(ctx context.Context, bs structures.BlockSelector) (*bind.CallOpts, error) {
+	co := &bind.CallOpts{
+		Context: ctx,
+	}
+
+	switch bs.Tag {
+	case structures.BlockNumber:
+		co.BlockNumber = new(big.Int).SetUint64(bs.Number)
+	case structures.BlockPending:
+		co.Pending = true
+	case structures.BlockLatest:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnresolvedBlock, bs)
+	}
+	return co, nil
+}
+
 type BoundContractCaller interface {
 	GetContract() *bind.BoundContract
+	// CallRaw calls the contract with packed input and returns undecoded output
+	CallRaw(opts *bind.CallOpts, input []byte) ([]byte, error)
+}
+
+// IsContractError reports if error was returned by the called contract (revert),
+// rather than caused by the node or connection
+func IsContractError(err error) bool {
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr.ErrorCode() == 3 || strings.Contains(rpcErr.Error(), "execution reverted")
+	}
+	return false
+}
+
+// rpcInvalidParams is the error code of invalid request params, nodes not knowing a block tag respond with it
+const rpcInvalidParams = -32602
+
+// IsTagUnsupported reports if node refused the block tag as unknown, as nodes predating safe and finalized tags do.
+// Transient errors never mean the tag is unsupported.
+func IsTagUnsupported(err error) bool {
+	if errors.Is(err, ethereum.NotFound) {
+		return true
+	}
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) || IsRetryable(err) {
+		return false
+	}
+	msg := strings.ToLower(rpcErr.Error())
+	return rpcErr.ErrorCode() == rpcInvalidParams ||
+		strings.Contains(msg, "invalid argument") ||
+		strings.Contains(msg, "not supported") ||
+		strings.Contains(msg, "unsupported") ||
+		strings.Contains(msg, "unknown block")
+}
+
+// HeaderByTag calls eth_getBlockByNumber with block tag, which ethclient does not support for safe and finalized
+func HeaderByTag(ctx context.Context, c *rpc.Client, tag string) (*types.Header, error) {
+	var head *types.Header
+	if err := c.CallContext(ctx, &head, "eth_getBlockByNumber", tag, false); err != nil {
+		return nil, err
+	}
+	if head == nil {
+		return nil, ethereum.NotFound
+	}
+	return head, nil
+}
+
+// Subscribable reports if node url supports subscriptions, which are available over WebSocket and IPC, but not HTTP
+func Subscribable(url string) bool {
+	url = strings.ToLower(url)
+	return !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://")
+}
+
+// PeerCount calls net_peerCount, which ethclient does not provide
+func PeerCount(ctx context.Context, c *rpc.Client) (uint64, error) {
+	var peers hexutil.Uint64
+	if err := c.CallContext(ctx, &peers, "net_peerCount"); err != nil {
+		return 0, err
+	}
+	return uint64(peers), nil
+}
+
+// NodeConn is a connection of a single node, calls made with it bypass retries, rate limits and circuit breakers
+type NodeConn struct {
+	URL string
+	RPC *rpc.Client
 }
 
 type EthereumTransport interface {
 	Dial(ctx context.Context) (err error)
 	Close(ctx context.Context)
 	GetBoundContractCaller(address common.Address, a abi.ABI) BoundContractCaller
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	// HeaderByTag returns header of the block by its tag (latest, safe, finalized, pending)
+	HeaderByTag(ctx context.Context, tag string) (*types.Header, error)
+	// History returns historical state availability found by the last probe
+	History() History
+	// Identity returns the chain identity verified on dial, zero when it was not verified yet
+	Identity() Identity
+	// Head returns the head received from new heads subscription, nil when transport is not subscribed
+	Head() *types.Header
+	// SyncProgress returns nil when node is not syncing
+	SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error)
+	PeerCount(ctx context.Context) (uint64, error)
+	// NodeConns returns connections of all the dialed nodes, so that health checks see every node as it is
+	NodeConns() []NodeConn
 }
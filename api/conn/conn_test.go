@@ -0,0 +1,36 @@
+package conn
+
+import (
+	"context"
+	"net/http"
+	"syscall"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestIsTagUnsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "null block", err: ethereum.NotFound, want: true},
+		{name: "invalid params", err: rpcError{code: rpcInvalidParams, msg: "invalid argument 0: hex string without 0x prefix"}, want: true},
+		{name: "unknown block message", err: rpcError{code: -32000, msg: "Unknown block"}, want: true},
+		{name: "other node error", err: rpcError{code: -32000, msg: "header not found"}, want: false},
+		{name: "rate limited", err: rpcError{code: rpcLimitExceeded, msg: "invalid argument, limit exceeded"}, want: false},
+		{name: "http 429", err: rpc.HTTPError{StatusCode: http.StatusTooManyRequests}, want: false},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, want: false},
+		{name: "connection reset", err: syscall.ECONNRESET, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTagUnsupported(tt.err); got != tt.want {
+				t.Errorf("IsTagUnsupported(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
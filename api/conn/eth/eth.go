@@ -2,37 +2,337 @@ package eth
 
 import (
 	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
 
 	"github.com/figment-networks/ethereum-worker/api/conn"
 )
 
 type EthTransport struct {
 	C   *ethclient.Client
+	RPC *rpc.Client
 	Url string
+
+	// ProbeInterval is the period of historical state probes
+	ProbeInterval time.Duration
+	// ExpectedChain is the chain node has to serve, Dial fails otherwise
+	ExpectedChain conn.Identity
+	// BatchMaxSize is the maximum number of eth_calls sent in one JSON-RPC batch, batching is disabled below 2
+	BatchMaxSize int
+	// BatchLinger is the time batch waits for more calls after the first one
+	BatchLinger time.Duration
+	// Retry is the retry policy of node calls
+	Retry conn.RetryPolicy
+	// MaxFailures is the number of consecutive failures opening node's circuit breaker
+	MaxFailures uint64
+	// Cooldown is the time after which open circuit breaker lets a trial call through
+	Cooldown time.Duration
+	// Limiter is the rate limit of node calls, nil does not limit them
+	Limiter *conn.Limiter
+
+	log     *zap.Logger
+	batcher *conn.Batcher
+	breaker *conn.Breaker
+
+	l        sync.RWMutex
+	history  conn.History
+	identity conn.Identity
+	// wrongChain is set when node serves other chain after reconnect, calls are refused until it is verified again
+	wrongChain error
+	head       *types.Header
+
+	closeOnce sync.Once
+	closeCh   chan struct{}
 }
 
-func NewEthTransport(url string) *EthTransport {
-	return &EthTransport{Url: url}
+func NewEthTransport(log *zap.Logger, url string) *EthTransport {
+	return &EthTransport{
+		Url:           url,
+		ProbeInterval: 5 * time.Minute,
+		BatchLinger:   5 * time.Millisecond,
+		Retry:         conn.DefaultRetryPolicy,
+		MaxFailures:   3,
+		Cooldown:      30 * time.Second,
+		log:           log,
+		closeCh:       make(chan struct{}),
+	}
 }
 
+// Dial connects the node and verifies the chain it serves. WebSocket and IPC
+// connections are subscribed to new heads, so the current head is known without polling.
 func (et *EthTransport) Dial(ctx context.Context) (err error) {
-	et.C, err = ethclient.DialContext(ctx, et.Url)
-	return err
+	if et.RPC, err = rpc.DialContext(ctx, et.Url); err != nil {
+		return err
+	}
+	et.C = ethclient.NewClient(et.RPC)
+	et.breaker = conn.NewBreaker(et.MaxFailures, et.Cooldown)
+	if et.BatchMaxSize > 1 {
+		et.batcher = conn.NewBatcher(et.RPC, et.BatchMaxSize, et.BatchLinger)
+		et.batcher.Limiter = et.Limiter
+	}
+
+	if err = et.verify(ctx); err != nil {
+		et.RPC.Close()
+		return err
+	}
+
+	et.probe(ctx)
+	go et.run()
+	if conn.Subscribable(et.Url) {
+		go et.followHeads()
+	}
+	return nil
 }
 
 func (et *EthTransport) Close(ctx context.Context) {
-	et.C.Close()
+	et.closeOnce.Do(func() {
+		close(et.closeCh)
+		et.C.Close()
+	})
+}
+
+// History returns historical state availability found by the last successful probe
+func (et *EthTransport) History() conn.History {
+	et.l.RLock()
+	defer et.l.RUnlock()
+	return et.history
+}
+
+// Identity returns the chain identity verified on dial
+func (et *EthTransport) Identity() conn.Identity {
+	et.l.RLock()
+	defer et.l.RUnlock()
+	return et.identity
+}
+
+// Head returns the head received from new heads subscription, nil while not subscribed
+func (et *EthTransport) Head() *types.Header {
+	et.l.RLock()
+	defer et.l.RUnlock()
+	return et.head
+}
+
+func (et *EthTransport) setHead(h *types.Header) {
+	et.l.Lock()
+	defer et.l.Unlock()
+	et.head = h
+}
+
+// verify checks the node serves the expected chain, the one verified on dial is expected after reconnect
+func (et *EthTransport) verify(ctx context.Context) error {
+	expected := et.ExpectedChain
+	if cur := et.Identity(); !cur.Verified.IsZero() {
+		expected = cur
+	}
+
+	id, err := conn.VerifyChain(ctx, et.RPC, expected)
+	et.l.Lock()
+	defer et.l.Unlock()
+	if err != nil {
+		if id.ChainID != 0 {
+			et.wrongChain = err
+		}
+		return err
+	}
+	et.identity = id
+	et.wrongChain = nil
+	return nil
+}
+
+// ready refuses calls while node serves other chain
+func (et *EthTransport) ready() error {
+	et.l.RLock()
+	defer et.l.RUnlock()
+	return et.wrongChain
+}
+
+// Breaker returns the state of node's circuit breaker
+func (et *EthTransport) Breaker() string {
+	return et.breaker.State()
+}
+
+// do calls the node with retries within its rate limit, calls are refused while node serves other chain
+// or its circuit breaker is open
+func (et *EthTransport) do(ctx context.Context, f func(ctx context.Context) error) error {
+	return et.call(ctx, et.Limiter, f)
+}
+
+// call charges lim on every attempt, batched calls pass nil as their batch request is charged by the batcher.
+// Only successful calls close the breaker and only transient errors of the node count as its failures,
+// calls rejected by the rate limit or ended by the caller's context leave the breaker untouched.
+// Failed batch request is counted once, not for each of its calls.
+func (et *EthTransport) call(ctx context.Context, lim *conn.Limiter, f func(ctx context.Context) error) error {
+	if err := et.ready(); err != nil {
+		return err
+	}
+	parent := ctx
+	return et.Retry.Do(ctx, func(ctx context.Context) error {
+		if !et.breaker.Allow() {
+			return conn.ErrCircuitOpen
+		}
+		if err := lim.Wait(ctx); err != nil {
+			return err
+		}
+		err := f(ctx)
+		switch {
+		case err == nil:
+			et.breaker.Success()
+		case parent.Err() != nil, errors.Is(err, conn.ErrQuotaExhausted):
+		case conn.IsRetryable(err) && conn.CountFailure(err):
+			et.breaker.Failure()
+		}
+		return err
+	})
+}
+
+func (et *EthTransport) run() {
+	tckr := time.NewTicker(et.ProbeInterval)
+	defer tckr.Stop()
+	for {
+		select {
+		case <-et.closeCh:
+			return
+		case <-tckr.C:
+			et.probe(context.Background())
+		}
+	}
+}
+
+// probe keeps the previous result on failure, node is probed again on the next tick
+func (et *EthTransport) probe(ctx context.Context) {
+	ctxT, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	h, err := conn.ProbeHistory(ctxT, et.C, et.Limiter)
+	if err != nil {
+		return
+	}
+	et.l.Lock()
+	et.history = h
+	et.l.Unlock()
 }
 
 func (et *EthTransport) GetBoundContractCaller(address common.Address, a abi.ABI) conn.BoundContractCaller {
 	return &BoundContractC{address: address, abi: a, ET: et}
 }
 
+// CodeAt implements bind.ContractCaller
+func (et *EthTransport) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) (code []byte, err error) {
+	err = et.do(ctx, func(ctx context.Context) (err error) {
+		code, err = et.C.CodeAt(ctx, contract, blockNumber)
+		return err
+	})
+	return code, err
+}
+
+// CallContract implements bind.ContractCaller
+func (et *EthTransport) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) (res []byte, err error) {
+	if et.batcher != nil {
+		err = et.call(ctx, nil, func(ctx context.Context) (err error) {
+			res, err = et.batcher.CallContract(ctx, call, blockNumber)
+			return err
+		})
+		return res, err
+	}
+	err = et.do(ctx, func(ctx context.Context) (err error) {
+		res, err = et.C.CallContract(ctx, call, blockNumber)
+		return err
+	})
+	return res, err
+}
+
+// PendingCodeAt implements bind.PendingContractCaller
+func (et *EthTransport) PendingCodeAt(ctx context.Context, contract common.Address) (code []byte, err error) {
+	err = et.do(ctx, func(ctx context.Context) (err error) {
+		code, err = et.C.PendingCodeAt(ctx, contract)
+		return err
+	})
+	return code, err
+}
+
+// PendingCallContract implements bind.PendingContractCaller
+func (et *EthTransport) PendingCallContract(ctx context.Context, call ethereum.CallMsg) (res []byte, err error) {
+	if et.batcher != nil {
+		err = et.call(ctx, nil, func(ctx context.Context) (err error) {
+			res, err = et.batcher.PendingCallContract(ctx, call)
+			return err
+		})
+		return res, err
+	}
+	err = et.do(ctx, func(ctx context.Context) (err error) {
+		res, err = et.C.PendingCallContract(ctx, call)
+		return err
+	})
+	return res, err
+}
+
+func (et *EthTransport) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (balance *big.Int, err error) {
+	err = et.do(ctx, func(ctx context.Context) (err error) {
+		balance, err = et.C.BalanceAt(ctx, account, blockNumber)
+		return err
+	})
+	return balance, err
+}
+
+func (et *EthTransport) PendingBalanceAt(ctx context.Context, account common.Address) (balance *big.Int, err error) {
+	err = et.do(ctx, func(ctx context.Context) (err error) {
+		balance, err = et.C.PendingBalanceAt(ctx, account)
+		return err
+	})
+	return balance, err
+}
+
+func (et *EthTransport) HeaderByNumber(ctx context.Context, number *big.Int) (header *types.Header, err error) {
+	err = et.do(ctx, func(ctx context.Context) (err error) {
+		header, err = et.C.HeaderByNumber(ctx, number)
+		return err
+	})
+	return header, err
+}
+
+func (et *EthTransport) HeaderByTag(ctx context.Context, tag string) (header *types.Header, err error) {
+	err = et.do(ctx, func(ctx context.Context) (err error) {
+		header, err = conn.HeaderByTag(ctx, et.RPC, tag)
+		return err
+	})
+	return header, err
+}
+
+func (et *EthTransport) SyncProgress(ctx context.Context) (progress *ethereum.SyncProgress, err error) {
+	err = et.do(ctx, func(ctx context.Context) (err error) {
+		progress, err = et.C.SyncProgress(ctx)
+		return err
+	})
+	return progress, err
+}
+
+func (et *EthTransport) PeerCount(ctx context.Context) (peers uint64, err error) {
+	err = et.do(ctx, func(ctx context.Context) (err error) {
+		peers, err = conn.PeerCount(ctx, et.RPC)
+		return err
+	})
+	return peers, err
+}
+
+// NodeConns returns the node connection, nil until it is dialed
+func (et *EthTransport) NodeConns() []conn.NodeConn {
+	if et.RPC == nil {
+		return nil
+	}
+	return []conn.NodeConn{{URL: et.Url, RPC: et.RPC}}
+}
+
 type BoundContractC struct {
 	address common.Address
 	abi     abi.ABI
@@ -40,6 +340,14 @@ type BoundContractC struct {
 }
 
 func (bcc *BoundContractC) GetContract() *bind.BoundContract {
-	return bind.NewBoundContract(bcc.address, bcc.abi, bcc.ET.C, nil, nil) // (lukanus): it's just a structure
+	return bind.NewBoundContract(bcc.address, bcc.abi, bcc.ET, nil, nil) // (lukanus): it's just a structure
+
+}
 
+func (bcc *BoundContractC) CallRaw(opts *bind.CallOpts, input []byte) ([]byte, error) {
+	msg := ethereum.CallMsg{From: opts.From, To: &bcc.address, Data: input}
+	if opts.Pending {
+		return bcc.ET.PendingCallContract(opts.Context, msg)
+	}
+	return bcc.ET.CallContract(opts.Context, msg, opts.BlockNumber)
 }
@@ -0,0 +1,93 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+)
+
+type revertError struct{}
+
+func (revertError) Error() string  { return "execution reverted" }
+func (revertError) ErrorCode() int { return 3 }
+
+func TestCallBreaker(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		// wait makes the call wait for its context, which ends by the caller's timeout or attempt timeout
+		wait           bool
+		timeout        time.Duration
+		attemptTimeout time.Duration
+		failures       uint64
+	}{
+		{name: "success", err: nil, failures: 0},
+		{name: "transient error", err: syscall.ECONNRESET, failures: 2},
+		{name: "contract error", err: revertError{}, failures: 1},
+		{name: "quota exhausted", err: conn.ErrQuotaExhausted, failures: 1},
+		{name: "caller deadline", wait: true, timeout: 10 * time.Millisecond, failures: 1},
+		{name: "attempt timeout", wait: true, timeout: time.Second, attemptTimeout: 10 * time.Millisecond, failures: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			et := NewEthTransport(zap.NewNop(), "http://node")
+			et.Retry = conn.RetryPolicy{MaxAttempts: 1, AttemptTimeout: tt.attemptTimeout}
+			et.breaker = conn.NewBreaker(3, time.Hour)
+			et.breaker.Failure() // previous failure is reset only by success
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			err := et.call(ctx, nil, func(ctx context.Context) error {
+				if tt.wait {
+					<-ctx.Done()
+					return ctx.Err()
+				}
+				return tt.err
+			})
+			if !tt.wait && err != tt.err {
+				t.Errorf("error = %v, want %v", err, tt.err)
+			}
+			if f := et.breaker.Failures(); f != tt.failures {
+				t.Errorf("failures = %d, want %d", f, tt.failures)
+			}
+		})
+	}
+}
+
+func TestCallBreakerOpen(t *testing.T) {
+	et := NewEthTransport(zap.NewNop(), "http://node")
+	et.Retry = conn.RetryPolicy{MaxAttempts: 1}
+	et.breaker = conn.NewBreaker(1, time.Hour)
+	et.breaker.Failure()
+	// a single token, next one comes in hours
+	lim := conn.NewLimiter("http://node", 0.0001, 1, time.Millisecond)
+
+	var calls int
+	f := func(ctx context.Context) error {
+		calls++
+		return nil
+	}
+	if err := et.call(context.Background(), lim, f); !errors.Is(err, conn.ErrCircuitOpen) {
+		t.Fatalf("error = %v, want %v", err, conn.ErrCircuitOpen)
+	}
+
+	// refused call is not charged to the rate limit
+	et.breaker.Success()
+	if err := et.call(context.Background(), lim, f); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
@@ -0,0 +1,17 @@
+package eth
+
+import (
+	"github.com/figment-networks/ethereum-worker/api/conn"
+)
+
+// followHeads keeps new heads subscription alive, node is verified again after reconnect
+func (et *EthTransport) followHeads() {
+	hf := &conn.HeadFollower{
+		URL:     et.Url,
+		C:       et.C,
+		Verify:  et.verify,
+		SetHead: et.setHead,
+		Log:     et.log,
+	}
+	hf.Run(et.closeCh)
+}
@@ -0,0 +1,92 @@
+package conn
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"go.uber.org/zap"
+)
+
+const (
+	minResubscribeDelay = time.Second
+	maxResubscribeDelay = time.Minute
+)
+
+var errSubscriptionClosed = errors.New("subscription closed")
+
+// HeadFollower keeps new heads subscription of a node alive. Dropped connection is reestablished by the rpc client
+// on resubscribe, node is verified again before its heads are trusted, as it may serve other chain after reconnect.
+type HeadFollower struct {
+	URL string
+	C   *ethclient.Client
+	// Verify checks the node after reconnect
+	Verify func(ctx context.Context) error
+	// SetHead receives every new head, and nil when the subscription drops
+	SetHead func(h *types.Header)
+	Log     *zap.Logger
+}
+
+// Run follows heads until closeCh is closed
+func (hf *HeadFollower) Run(closeCh <-chan struct{}) {
+	delay := minResubscribeDelay
+	for reconnect := false; ; reconnect = true {
+		if reconnect {
+			select {
+			case <-closeCh:
+				return
+			case <-time.After(delay):
+			}
+			if delay *= 2; delay > maxResubscribeDelay {
+				delay = maxResubscribeDelay
+			}
+
+			ctxT, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			err := hf.Verify(ctxT)
+			cancel()
+			if err != nil {
+				hf.Log.Error("Error verifying ethereum node after reconnect", zap.String("url", hf.URL), zap.Error(err))
+				continue
+			}
+		}
+
+		received, err := hf.subscribe(closeCh)
+		hf.SetHead(nil)
+		if err == nil {
+			return
+		}
+		if received {
+			delay = minResubscribeDelay
+		}
+		hf.Log.Warn("New heads subscription dropped, resubscribing", zap.String("url", hf.URL), zap.Duration("delay", delay), zap.Error(err))
+	}
+}
+
+// subscribe follows heads until subscription fails or closeCh is closed, which returns nil error
+func (hf *HeadFollower) subscribe(closeCh <-chan struct{}) (received bool, err error) {
+	ctxT, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	heads := make(chan *types.Header, 16)
+	sub, err := hf.C.SubscribeNewHead(ctxT, heads)
+	cancel()
+	if err != nil {
+		return false, err
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case <-closeCh:
+			return received, nil
+		case err, ok := <-sub.Err():
+			if !ok {
+				err = errSubscriptionClosed
+			}
+			return received, err
+		case h := <-heads:
+			received = true
+			hf.SetHead(h)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package conn
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
+)
+
+// headsService notifies subscribers of the heads sent to it
+type headsService struct {
+	heads chan *types.Header
+}
+
+func (hs *headsService) NewHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, ok := rpc.NotifierFromContext(ctx)
+	if !ok {
+		return nil, rpc.ErrNotificationsUnsupported
+	}
+	sub := notifier.CreateSubscription()
+	go func() {
+		for {
+			select {
+			case h := <-hs.heads:
+				notifier.Notify(sub.ID, h)
+			case <-sub.Err():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+func TestHeadFollower(t *testing.T) {
+	hs := &headsService{heads: make(chan *types.Header)}
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("eth", hs); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+	c := rpc.DialInProc(srv)
+	defer c.Close()
+
+	var (
+		l     sync.Mutex
+		heads []*types.Header
+	)
+	received := make(chan struct{}, 10)
+	hf := &HeadFollower{
+		URL:    "ws://node",
+		C:      ethclient.NewClient(c),
+		Verify: func(ctx context.Context) error { return nil },
+		SetHead: func(h *types.Header) {
+			l.Lock()
+			heads = append(heads, h)
+			l.Unlock()
+			received <- struct{}{}
+		},
+		Log: zap.NewNop(),
+	}
+
+	closeCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		hf.Run(closeCh)
+		close(done)
+	}()
+
+	for i := int64(1); i <= 2; i++ {
+		hs.heads <- &types.Header{Number: big.NewInt(i), Difficulty: big.NewInt(0), Extra: []byte{}}
+		select {
+		case <-received:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("head %d not received", i)
+		}
+	}
+
+	close(closeCh)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("follower not stopped")
+	}
+
+	l.Lock()
+	defer l.Unlock()
+	if len(heads) != 3 || heads[0].Number.Int64() != 1 || heads[1].Number.Int64() != 2 || heads[2] != nil {
+		t.Errorf("heads = %v, want 1, 2 and nil when stopped", heads)
+	}
+}
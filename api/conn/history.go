@@ -0,0 +1,116 @@
+package conn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// History describes how far back node serves historical state
+type History struct {
+	// Archive is set when node serves state of all the blocks
+	Archive bool `json:"archive"`
+	// Oldest is the oldest block node served state of at the time of the probe
+	Oldest uint64 `json:"oldest"`
+	// Probed is the time of the last successful probe, zero when node was not probed yet
+	Probed time.Time `json:"probed"`
+}
+
+// Available reports if state of given block is served. Nodes not probed yet are assumed to serve any block.
+func (h History) Available(height uint64) bool {
+	return h.Probed.IsZero() || h.Archive || height >= h.Oldest
+}
+
+// prunedStateErrors are parts of the errors nodes and providers answer calls at blocks of pruned state with
+var prunedStateErrors = []string{
+	"missing trie node",
+	"state is not available",
+	"state not available",
+	"historical state",
+	"world state unavailable",
+	"archive state",
+	"pruned",
+}
+
+// ProbeHistory finds the oldest block node serves state of, calling eth_call at historic blocks.
+// Block 1 is checked first, as archive nodes are the common case, then the range up to the head is bisected.
+// Every call is charged to lim as any other call of the node.
+func ProbeHistory(ctx context.Context, c *ethclient.Client, lim *Limiter) (h History, err error) {
+	if err = lim.Wait(ctx); err != nil {
+		return h, err
+	}
+	head, err := c.BlockNumber(ctx)
+	if err != nil {
+		return h, fmt.Errorf("error getting head: %w", err)
+	}
+
+	ok, err := stateAvailable(ctx, c, lim, 1)
+	if err != nil {
+		return h, err
+	}
+	if ok {
+		return History{Archive: true, Oldest: 0, Probed: time.Now()}, nil
+	}
+
+	if ok, err = stateAvailable(ctx, c, lim, head); err != nil {
+		return h, err
+	}
+	if !ok {
+		return h, fmt.Errorf("state of head block %d is not available", head)
+	}
+
+	// invariant: state of lo is not available, state of hi is
+	lo, hi := uint64(1), head
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		if ok, err = stateAvailable(ctx, c, lim, mid); err != nil {
+			return h, err
+		}
+		if ok {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+
+	return History{Oldest: hi, Probed: time.Now()}, nil
+}
+
+// stateAvailable calls empty address at given block. Errors of missing trie node or pruned state mean
+// state is not available, other errors are returned.
+func stateAvailable(ctx context.Context, c *ethclient.Client, lim *Limiter, height uint64) (bool, error) {
+	if err := lim.Wait(ctx); err != nil {
+		return false, err
+	}
+	_, err := c.CallContract(ctx, ethereum.CallMsg{To: &common.Address{}}, new(big.Int).SetUint64(height))
+	if err == nil {
+		return true, nil
+	}
+	if isPrunedState(err) {
+		return false, nil
+	}
+	return false, fmt.Errorf("error calling at block %d: %w", height, err)
+}
+
+// isPrunedState checks if err is node's answer that it does not serve state of the block
+func isPrunedState(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	msg := strings.ToLower(rpcErr.Error())
+	for _, s := range prunedStateErrors {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package conn
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// stateService serves eth_blockNumber and eth_call, state of blocks below oldest is pruned
+type stateService struct {
+	head   uint64
+	oldest uint64
+	calls  int
+	// pruned is the error of calls at pruned blocks, missing trie node when not set
+	pruned error
+}
+
+func (s *stateService) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(s.head)
+}
+
+func (s *stateService) Call(args map[string]interface{}, block string) (hexutil.Bytes, error) {
+	s.calls++
+	n, err := hexutil.DecodeUint64(block)
+	if err != nil {
+		return nil, err
+	}
+	if n < s.oldest {
+		if s.pruned != nil {
+			return nil, s.pruned
+		}
+		return nil, errors.New("missing trie node")
+	}
+	return hexutil.Bytes{}, nil
+}
+
+func TestProbeHistory(t *testing.T) {
+	tests := []struct {
+		name    string
+		svc     *stateService
+		lim     *Limiter
+		want    History
+		wantErr bool
+	}{
+		{name: "archive node", svc: &stateService{head: 1000000}, want: History{Archive: true}},
+		{name: "pruned node", svc: &stateService{head: 1000000, oldest: 999873}, want: History{Oldest: 999873}},
+		{name: "only head available", svc: &stateService{head: 1000000, oldest: 1000000}, want: History{Oldest: 1000000}},
+		{name: "block 2 is the oldest", svc: &stateService{head: 1000000, oldest: 2}, want: History{Oldest: 2}},
+		{name: "head not available", svc: &stateService{head: 1000000, oldest: 1000001}, wantErr: true},
+		{name: "provider without archive state", svc: &stateService{head: 1000000, oldest: 999873, pruned: errors.New("project ID does not have access to archive state")}, want: History{Oldest: 999873}},
+		{name: "node error", svc: &stateService{head: 1000000, oldest: 999873, pruned: errors.New("rate limit exceeded")}, wantErr: true},
+		{name: "rate limited", svc: &stateService{head: 1000000}, lim: NewLimiter("http://node", 0.0001, 1, 10*time.Millisecond), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := rpc.NewServer()
+			if err := srv.RegisterName("eth", tt.svc); err != nil {
+				t.Fatal(err)
+			}
+			defer srv.Stop()
+			c := ethclient.NewClient(rpc.DialInProc(srv))
+			defer c.Close()
+
+			h, err := ProbeHistory(context.Background(), c, tt.lim)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProbeHistory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if h.Archive != tt.want.Archive || h.Oldest != tt.want.Oldest || h.Probed.IsZero() {
+				t.Errorf("ProbeHistory() = %+v, want %+v", h, tt.want)
+			}
+			// bisection needs about log2(head) calls
+			if tt.svc.calls > 25 {
+				t.Errorf("ProbeHistory() made %d calls", tt.svc.calls)
+			}
+		})
+	}
+}
+
+func TestHistoryAvailable(t *testing.T) {
+	tests := []struct {
+		name   string
+		h      History
+		height uint64
+		want   bool
+	}{
+		{name: "not probed", h: History{Oldest: 100}, height: 1, want: true},
+		{name: "archive", h: History{Archive: true, Probed: time.Now()}, height: 1, want: true},
+		{name: "below oldest", h: History{Oldest: 100, Probed: time.Now()}, height: 99, want: false},
+		{name: "oldest", h: History{Oldest: 100, Probed: time.Now()}, height: 100, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.h.Available(tt.height); got != tt.want {
+				t.Errorf("Available(%d) = %v, want %v", tt.height, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package conn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrWrongChain is returned when node serves other chain than the expected one
+var ErrWrongChain = errors.New("node serves a different chain")
+
+// Identity identifies the chain node serves. Zero fields of the expected identity are not checked.
+type Identity struct {
+	ChainID  uint64      `json:"chainId"`
+	Genesis  common.Hash `json:"genesis"`
+	Verified time.Time   `json:"verified"`
+}
+
+// VerifyChain reads chain id and genesis hash from the node and compares them with the expected ones.
+// Genesis hash is taken from the node rather than computed from the header, so chains with header fields
+// unknown to this client are identified correctly as well.
+func VerifyChain(ctx context.Context, c *rpc.Client, expected Identity) (id Identity, err error) {
+	var chainID hexutil.Uint64
+	if err = c.CallContext(ctx, &chainID, "eth_chainId"); err != nil {
+		return id, fmt.Errorf("error reading chain id: %w", err)
+	}
+
+	var genesis struct {
+		Hash common.Hash `json:"hash"`
+	}
+	if err = c.CallContext(ctx, &genesis, "eth_getBlockByNumber", "0x0", false); err != nil {
+		return id, fmt.Errorf("error reading genesis block: %w", err)
+	}
+
+	id = Identity{ChainID: uint64(chainID), Genesis: genesis.Hash, Verified: time.Now()}
+	if expected.ChainID != 0 && expected.ChainID != id.ChainID {
+		return id, fmt.Errorf("%w: chain id is %d, expected %d", ErrWrongChain, id.ChainID, expected.ChainID)
+	}
+	if expected.Genesis != (common.Hash{}) && expected.Genesis != id.Genesis {
+		return id, fmt.Errorf("%w: genesis is %s, expected %s", ErrWrongChain, id.Genesis.Hex(), expected.Genesis.Hex())
+	}
+	return id, nil
+}
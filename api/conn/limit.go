@@ -0,0 +1,119 @@
+package conn
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/figment-networks/indexing-engine/metrics"
+	"golang.org/x/time/rate"
+)
+
+// ErrQuotaExhausted is returned when the call would have to wait for node's rate limit longer than allowed
+var ErrQuotaExhausted = errors.New("upstream quota exhausted")
+
+// DefaultRateMaxWait is the longest call waits for the rate limit with no wait configured
+const DefaultRateMaxWait = time.Second
+
+var (
+	rateQueuedMetric = metrics.MustNewCounterWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "node",
+		Name:      "rate_limit_queued",
+		Desc:      "Number of calls queued by node's rate limit",
+		Tags:      []string{"node"},
+	})
+
+	rateRejectedMetric = metrics.MustNewCounterWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "node",
+		Name:      "rate_limit_rejected",
+		Desc:      "Number of calls rejected by node's rate limit as upstream quota exhausted",
+		Tags:      []string{"node"},
+	})
+
+	rateWaitingMetric = metrics.MustNewGaugeWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "node",
+		Name:      "rate_limit_waiting",
+		Desc:      "Number of calls currently waiting for node's rate limit",
+		Tags:      []string{"node"},
+	})
+)
+
+// Limiter is a token bucket rate limit of calls to a single node. Calls over the limit are queued
+// until MaxWait or their context deadline, calls that would wait longer are rejected right away.
+type Limiter struct {
+	l       *rate.Limiter
+	maxWait time.Duration
+
+	queuedM   metrics.Counter
+	rejectedM metrics.Counter
+	waitingM  metrics.Gauge
+}
+
+// NewLimiter is Limiter constructor. Node url is reported in metrics by its host only, as urls often contain api keys.
+func NewLimiter(nodeURL string, rps float64, burst int, maxWait time.Duration) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	if maxWait <= 0 {
+		maxWait = DefaultRateMaxWait
+	}
+	name := nodeName(nodeURL)
+	return &Limiter{
+		l:         rate.NewLimiter(rate.Limit(rps), burst),
+		maxWait:   maxWait,
+		queuedM:   rateQueuedMetric.WithLabels(name),
+		rejectedM: rateRejectedMetric.WithLabels(name),
+		waitingM:  rateWaitingMetric.WithLabels(name),
+	}
+}
+
+// Wait blocks until the call may be made. Nil limiter does not limit calls.
+func (lim *Limiter) Wait(ctx context.Context) error {
+	if lim == nil {
+		return nil
+	}
+
+	now := time.Now()
+	r := lim.l.ReserveN(now, 1)
+	delay := r.DelayFrom(now)
+	if delay == 0 {
+		return nil
+	}
+
+	wait := lim.maxWait
+	if deadline, ok := ctx.Deadline(); ok && deadline.Sub(now) < wait {
+		wait = deadline.Sub(now)
+	}
+	if !r.OK() || delay > wait {
+		r.CancelAt(now)
+		lim.rejectedM.Inc()
+		return ErrQuotaExhausted
+	}
+
+	lim.queuedM.Inc()
+	lim.waitingM.Inc()
+	defer lim.waitingM.Dec()
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+func nodeName(nodeURL string) string {
+	u, err := url.Parse(nodeURL)
+	if err != nil || u.Host == "" { // IPC socket path
+		return filepath.Base(nodeURL)
+	}
+	return u.Host
+}
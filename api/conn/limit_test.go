@@ -0,0 +1,127 @@
+package conn
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiterWait(t *testing.T) {
+	tests := []struct {
+		name     string
+		rps      float64
+		maxWait  time.Duration
+		timeout  time.Duration
+		calls    int
+		err      error
+		min, max time.Duration
+	}{
+		{
+			name:    "within burst",
+			rps:     1,
+			maxWait: time.Second,
+			calls:   2,
+			max:     10 * time.Millisecond,
+		}, {
+			name:    "queued",
+			rps:     20,
+			maxWait: time.Second,
+			calls:   3,
+			min:     40 * time.Millisecond,
+			max:     time.Second,
+		}, {
+			name:    "rejected over max wait",
+			rps:     1,
+			maxWait: 10 * time.Millisecond,
+			calls:   3,
+			err:     ErrQuotaExhausted,
+			max:     10 * time.Millisecond,
+		}, {
+			name:    "rejected over call deadline",
+			rps:     1,
+			maxWait: time.Minute,
+			timeout: 20 * time.Millisecond,
+			calls:   3,
+			err:     ErrQuotaExhausted,
+			max:     10 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lim := NewLimiter("https://node.example.com/v3/key", tt.rps, 2, tt.maxWait)
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			start := time.Now()
+			var err error
+			for i := 0; i < tt.calls && err == nil; i++ {
+				err = lim.Wait(ctx)
+			}
+			elapsed := time.Since(start)
+
+			if !errors.Is(err, tt.err) {
+				t.Errorf("error = %v, want %v", err, tt.err)
+			}
+			if elapsed < tt.min || elapsed > tt.max {
+				t.Errorf("waited %s, want between %s and %s", elapsed, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestLimiterCancelled(t *testing.T) {
+	lim := NewLimiter("https://node.example.com", 10, 1, time.Second)
+	if err := lim.Wait(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	if err := lim.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want %v", err, context.Canceled)
+	}
+
+	// token of cancelled call is given back, so the next call does not wait for two of them
+	start := time.Now()
+	if err := lim.Wait(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("waited %s, want at most one token", elapsed)
+	}
+}
+
+func TestLimiterNil(t *testing.T) {
+	var lim *Limiter
+	if err := lim.Wait(context.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNodeName(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://mainnet.infura.io/v3/secret", want: "mainnet.infura.io"},
+		{url: "ws://127.0.0.1:8546", want: "127.0.0.1:8546"},
+		{url: "/var/run/geth.ipc", want: "geth.ipc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := nodeName(tt.url); got != tt.want {
+				t.Errorf("nodeName(%s) = %s, want %s", tt.url, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,627 @@
+package multi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+)
+
+var ErrNoNodesAvailable = conn.ErrNoNodesAvailable
+
+// Node is a single ethereum node with its health data
+type Node struct {
+	URL string
+	C   *ethclient.Client
+	RPC *rpc.Client
+	// B batches node's eth_calls, nil when batching is disabled
+	B *conn.Batcher
+
+	l        sync.RWMutex
+	breaker  *conn.Breaker
+	limiter  *conn.Limiter
+	head     uint64
+	header   *types.Header
+	latency  time.Duration
+	history  conn.History
+	identity conn.Identity
+}
+
+// NodeStatus is a snapshot of node health
+type NodeStatus struct {
+	URL      string        `json:"url"`
+	Healthy  bool          `json:"healthy"`
+	Failures uint64        `json:"failures"`
+	Breaker  string        `json:"breaker"`
+	Head     uint64        `json:"head"`
+	Latency  time.Duration `json:"latency"`
+	History  conn.History  `json:"history"`
+	Identity conn.Identity `json:"identity"`
+}
+
+func (n *Node) success(latency time.Duration) {
+	n.breaker.Success()
+	n.l.Lock()
+	defer n.l.Unlock()
+	if n.latency == 0 {
+		n.latency = latency
+	} else { // moving average, recent calls weigh 1/4
+		n.latency = (n.latency*3 + latency) / 4
+	}
+}
+
+func (n *Node) failure() {
+	n.breaker.Failure()
+}
+
+func (n *Node) setHead(head uint64) {
+	n.l.Lock()
+	defer n.l.Unlock()
+	n.head = head
+}
+
+// setHeader keeps the head received from subscription, nil when subscription dropped
+func (n *Node) setHeader(h *types.Header) {
+	n.l.Lock()
+	defer n.l.Unlock()
+	n.header = h
+	if h != nil {
+		n.head = h.Number.Uint64()
+	}
+}
+
+func (n *Node) setHistory(h conn.History) {
+	n.l.Lock()
+	defer n.l.Unlock()
+	n.history = h
+}
+
+// MultiTransport is an EthereumTransport routing calls over several nodes.
+// Nodes are ordered by health and calls fail over to the next node on transport errors.
+// Nodes with open circuit breaker are skipped, calls failed on all the nodes are retried.
+type MultiTransport struct {
+	nodes []*Node
+	log   *zap.Logger
+
+	// MaxFailures is the number of consecutive failures opening node's circuit breaker
+	MaxFailures uint64
+	// Cooldown is the time after which open circuit breaker lets a trial call through
+	Cooldown time.Duration
+	// Retry is the retry policy of calls, its attempt timeout bounds calls of single nodes
+	Retry conn.RetryPolicy
+	// Limiters are rate limits of node calls by node url, nodes without limiter are not limited
+	Limiters map[string]*conn.Limiter
+	// MaxLag is the number of blocks node may stay behind the best known head
+	MaxLag uint64
+	// CheckInterval is the period of background head checks
+	CheckInterval time.Duration
+	// ProbeInterval is the period of historical state probes
+	ProbeInterval time.Duration
+	// ExpectedChain is the chain nodes have to serve. When it is not set,
+	// all the nodes have to serve the same chain as the first verified one.
+	ExpectedChain conn.Identity
+	// BatchMaxSize is the maximum number of eth_calls sent in one JSON-RPC batch, batching is disabled below 2
+	BatchMaxSize int
+	// BatchLinger is the time batch waits for more calls after the first one
+	BatchLinger time.Duration
+
+	identityL sync.RWMutex
+	identity  conn.Identity
+
+	closeOnce sync.Once
+	closeCh   chan struct{}
+}
+
+// NewMultiTransport is MultiTransport constructor, urls are in order of preference
+func NewMultiTransport(log *zap.Logger, urls []string) *MultiTransport {
+	mt := &MultiTransport{
+		log:           log,
+		MaxFailures:   3,
+		Cooldown:      30 * time.Second,
+		MaxLag:        5,
+		CheckInterval: 10 * time.Second,
+		ProbeInterval: 5 * time.Minute,
+		Retry:         conn.DefaultRetryPolicy,
+		BatchLinger:   5 * time.Millisecond,
+		closeCh:       make(chan struct{}),
+	}
+	for _, u := range urls {
+		mt.nodes = append(mt.nodes, &Node{URL: u})
+	}
+	return mt
+}
+
+// Dial connects all the nodes and verifies the chain they serve. It fails only if none of the nodes
+// could be dialed and verified, the others are redialed on periodic checks.
+func (mt *MultiTransport) Dial(ctx context.Context) (err error) {
+	var dialed int
+	for _, n := range mt.nodes {
+		n.breaker = conn.NewBreaker(mt.MaxFailures, mt.Cooldown)
+		n.limiter = mt.Limiters[n.URL]
+		if err = mt.dialNode(ctx, n); err != nil {
+			mt.log.Error("Error dialing ethereum node", zap.String("url", n.URL), zap.Error(err))
+			continue
+		}
+		dialed++
+	}
+	if dialed == 0 {
+		return fmt.Errorf("error dialing nodes: %w", ErrNoNodesAvailable)
+	}
+
+	mt.checkHeads(ctx)
+	mt.probeHistory(ctx)
+	go mt.run()
+	return nil
+}
+
+// Close stops health checks and closes all the connections
+func (mt *MultiTransport) Close(ctx context.Context) {
+	mt.closeOnce.Do(func() {
+		close(mt.closeCh)
+		for _, n := range mt.nodes {
+			if n.C != nil {
+				n.C.Close()
+			}
+		}
+	})
+}
+
+// dialNode connects the node, it is used for calls only after it is verified to serve the expected chain
+func (mt *MultiTransport) dialNode(ctx context.Context, n *Node) error {
+	ctxT, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	c, err := rpc.DialContext(ctxT, n.URL)
+	if err != nil {
+		return err
+	}
+
+	expected := mt.ExpectedChain
+	if expected.ChainID == 0 && expected.Genesis == (common.Hash{}) {
+		expected = mt.Identity()
+	}
+	id, err := conn.VerifyChain(ctxT, c, expected)
+	if err != nil {
+		c.Close()
+		return err
+	}
+
+	mt.identityL.Lock()
+	if mt.identity.Verified.IsZero() {
+		mt.identity = id
+	}
+	mt.identityL.Unlock()
+
+	n.l.Lock()
+	n.RPC = c
+	n.C = ethclient.NewClient(c)
+	if mt.BatchMaxSize > 1 {
+		n.B = conn.NewBatcher(c, mt.BatchMaxSize, mt.BatchLinger)
+		n.B.Limiter = n.limiter
+	}
+	n.identity = id
+	n.l.Unlock()
+
+	if conn.Subscribable(n.URL) {
+		go mt.followHeads(n, c)
+	}
+	return nil
+}
+
+// followHeads keeps new heads subscription of the node alive, node is verified again after reconnect
+func (mt *MultiTransport) followHeads(n *Node, c *rpc.Client) {
+	hf := &conn.HeadFollower{
+		URL: n.URL,
+		C:   ethclient.NewClient(c),
+		Verify: func(ctx context.Context) error {
+			_, err := conn.VerifyChain(ctx, c, mt.Identity())
+			return err
+		},
+		SetHead: n.setHeader,
+		Log:     mt.log,
+	}
+	hf.Run(mt.closeCh)
+}
+
+// Identity returns the chain identity verified on the first dialed node
+func (mt *MultiTransport) Identity() conn.Identity {
+	mt.identityL.RLock()
+	defer mt.identityL.RUnlock()
+	return mt.identity
+}
+
+func (mt *MultiTransport) GetBoundContractCaller(address common.Address, a abi.ABI) conn.BoundContractCaller {
+	return &BoundContractC{address: address, abi: a, MT: mt}
+}
+
+// Status returns health snapshot of all the nodes
+func (mt *MultiTransport) Status() []NodeStatus {
+	st := make([]NodeStatus, 0, len(mt.nodes))
+	for _, n := range mt.nodes {
+		breaker := n.breaker.State()
+		n.l.RLock()
+		st = append(st, NodeStatus{
+			URL:      n.URL,
+			Healthy:  n.C != nil && breaker != conn.BreakerOpen,
+			Failures: n.breaker.Failures(),
+			Breaker:  breaker,
+			Head:     n.head,
+			Latency:  n.latency,
+			History:  n.history,
+			Identity: n.identity,
+		})
+		n.l.RUnlock()
+	}
+	return st
+}
+
+// History merges history of the probed nodes, state is available if any of them serves it
+func (mt *MultiTransport) History() (h conn.History) {
+	for _, n := range mt.nodes {
+		n.l.RLock()
+		nh := n.history
+		n.l.RUnlock()
+		if nh.Probed.IsZero() {
+			continue
+		}
+		if h.Probed.IsZero() || nh.Oldest < h.Oldest {
+			h.Oldest = nh.Oldest
+		}
+		if nh.Probed.After(h.Probed) {
+			h.Probed = nh.Probed
+		}
+		h.Archive = h.Archive || nh.Archive
+	}
+	return h
+}
+
+func (mt *MultiTransport) run() {
+	tckr := time.NewTicker(mt.CheckInterval)
+	defer tckr.Stop()
+	probeTckr := time.NewTicker(mt.ProbeInterval)
+	defer probeTckr.Stop()
+	for {
+		select {
+		case <-mt.closeCh:
+			return
+		case <-tckr.C:
+			mt.checkHeads(context.Background())
+		case <-probeTckr.C:
+			mt.probeHistory(context.Background())
+		}
+	}
+}
+
+// probeHistory keeps the previous result of nodes that failed, they are probed again on the next tick
+func (mt *MultiTransport) probeHistory(ctx context.Context) {
+	wg := &sync.WaitGroup{}
+	for _, n := range mt.nodes {
+		if n.C == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(n *Node) {
+			defer wg.Done()
+			ctxT, cancel := context.WithTimeout(ctx, time.Minute)
+			defer cancel()
+			h, err := conn.ProbeHistory(ctxT, n.C, n.limiter)
+			if err != nil {
+				mt.log.Warn("Error probing ethereum node history", zap.String("url", n.URL), zap.Error(err))
+				return
+			}
+			n.setHistory(h)
+		}(n)
+	}
+	wg.Wait()
+}
+
+func (mt *MultiTransport) checkHeads(ctx context.Context) {
+	wg := &sync.WaitGroup{}
+	for _, n := range mt.nodes {
+		if n.C == nil {
+			wg.Add(1)
+			go func(n *Node) {
+				defer wg.Done()
+				if err := mt.dialNode(ctx, n); err != nil {
+					mt.log.Warn("Error redialing ethereum node", zap.String("url", n.URL), zap.Error(err))
+				}
+			}(n)
+			continue
+		}
+		wg.Add(1)
+		go func(n *Node) {
+			defer wg.Done()
+			ctxT, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			now := time.Now()
+			head, err := n.C.BlockNumber(ctxT)
+			if err != nil {
+				mt.log.Warn("Error checking ethereum node head", zap.String("url", n.URL), zap.Error(err))
+				n.failure()
+				return
+			}
+			n.success(time.Since(now))
+			n.setHead(head)
+		}(n)
+	}
+	wg.Wait()
+}
+
+type rankedNode struct {
+	n    *Node
+	rank int
+}
+
+// ordered returns dialed nodes from the healthiest one. Nodes with open circuit breaker,
+// known to be behind minHeight or to have its state pruned are skipped.
+func (mt *MultiTransport) ordered(minHeight uint64) []*Node {
+	var maxHead uint64
+	for _, n := range mt.nodes {
+		n.l.RLock()
+		if n.head > maxHead {
+			maxHead = n.head
+		}
+		n.l.RUnlock()
+	}
+
+	ranked := make([]rankedNode, 0, len(mt.nodes))
+	for _, n := range mt.nodes {
+		breaker := n.breaker.State()
+		if breaker == conn.BreakerOpen {
+			continue
+		}
+		n.l.RLock()
+		rn := rankedNode{n: n}
+		if n.C == nil || n.head > 0 && n.head < minHeight || minHeight > 0 && !n.history.Available(minHeight) {
+			n.l.RUnlock()
+			continue
+		}
+		if breaker == conn.BreakerHalfOpen {
+			rn.rank += 2
+		}
+		if n.head+mt.MaxLag < maxHead {
+			rn.rank++
+		}
+		n.l.RUnlock()
+		ranked = append(ranked, rn)
+	}
+
+	// stable sort keeps configured order for nodes of the same rank
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].rank < ranked[j].rank
+	})
+
+	nodes := make([]*Node, len(ranked))
+	for i, rn := range ranked {
+		nodes[i] = rn.n
+	}
+	return nodes
+}
+
+// CodeAt implements bind.ContractCaller
+func (mt *MultiTransport) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) (code []byte, err error) {
+	err = mt.do(ctx, minHeight(blockNumber), func(ctx context.Context, c *ethclient.Client) (err error) {
+		code, err = c.CodeAt(ctx, contract, blockNumber)
+		return err
+	})
+	return code, err
+}
+
+// CallContract implements bind.ContractCaller
+func (mt *MultiTransport) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) (res []byte, err error) {
+	err = mt.call(ctx, minHeight(blockNumber), true, func(ctx context.Context, n *Node) (err error) {
+		if n.B != nil {
+			res, err = n.B.CallContract(ctx, call, blockNumber)
+			return err
+		}
+		res, err = n.C.CallContract(ctx, call, blockNumber)
+		return err
+	})
+	return res, err
+}
+
+// PendingCodeAt implements bind.PendingContractCaller
+func (mt *MultiTransport) PendingCodeAt(ctx context.Context, contract common.Address) (code []byte, err error) {
+	err = mt.do(ctx, 0, func(ctx context.Context, c *ethclient.Client) (err error) {
+		code, err = c.PendingCodeAt(ctx, contract)
+		return err
+	})
+	return code, err
+}
+
+// PendingCallContract implements bind.PendingContractCaller
+func (mt *MultiTransport) PendingCallContract(ctx context.Context, call ethereum.CallMsg) (res []byte, err error) {
+	err = mt.call(ctx, 0, true, func(ctx context.Context, n *Node) (err error) {
+		if n.B != nil {
+			res, err = n.B.PendingCallContract(ctx, call)
+			return err
+		}
+		res, err = n.C.PendingCallContract(ctx, call)
+		return err
+	})
+	return res, err
+}
+
+// BalanceAt returns native balance of an account
+func (mt *MultiTransport) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (balance *big.Int, err error) {
+	err = mt.do(ctx, minHeight(blockNumber), func(ctx context.Context, c *ethclient.Client) (err error) {
+		balance, err = c.BalanceAt(ctx, account, blockNumber)
+		return err
+	})
+	return balance, err
+}
+
+// PendingBalanceAt returns native balance of an account in the pending state
+func (mt *MultiTransport) PendingBalanceAt(ctx context.Context, account common.Address) (balance *big.Int, err error) {
+	err = mt.do(ctx, 0, func(ctx context.Context, c *ethclient.Client) (err error) {
+		balance, err = c.PendingBalanceAt(ctx, account)
+		return err
+	})
+	return balance, err
+}
+
+// HeaderByNumber returns block header, nil number means the latest header
+func (mt *MultiTransport) HeaderByNumber(ctx context.Context, number *big.Int) (header *types.Header, err error) {
+	err = mt.do(ctx, minHeight(number), func(ctx context.Context, c *ethclient.Client) (err error) {
+		header, err = c.HeaderByNumber(ctx, number)
+		return err
+	})
+	return header, err
+}
+
+// HeaderByTag returns header of the block by its tag
+func (mt *MultiTransport) HeaderByTag(ctx context.Context, tag string) (header *types.Header, err error) {
+	err = mt.doNode(ctx, 0, func(ctx context.Context, n *Node) (err error) {
+		header, err = conn.HeaderByTag(ctx, n.RPC, tag)
+		return err
+	})
+	return header, err
+}
+
+// Head returns the head received from subscription of the node calls are routed to, nil when it is not subscribed
+func (mt *MultiTransport) Head() *types.Header {
+	nodes := mt.ordered(0)
+	if len(nodes) == 0 {
+		return nil
+	}
+	nodes[0].l.RLock()
+	defer nodes[0].l.RUnlock()
+	return nodes[0].header
+}
+
+// SyncProgress returns sync progress of the node calls are routed to
+func (mt *MultiTransport) SyncProgress(ctx context.Context) (progress *ethereum.SyncProgress, err error) {
+	err = mt.do(ctx, 0, func(ctx context.Context, c *ethclient.Client) (err error) {
+		progress, err = c.SyncProgress(ctx)
+		return err
+	})
+	return progress, err
+}
+
+// PeerCount returns peer count of the node calls are routed to
+func (mt *MultiTransport) PeerCount(ctx context.Context) (peers uint64, err error) {
+	err = mt.doNode(ctx, 0, func(ctx context.Context, n *Node) (err error) {
+		peers, err = conn.PeerCount(ctx, n.RPC)
+		return err
+	})
+	return peers, err
+}
+
+// NodeConns returns connections of the dialed nodes in configured order
+func (mt *MultiTransport) NodeConns() []conn.NodeConn {
+	ncs := make([]conn.NodeConn, 0, len(mt.nodes))
+	for _, n := range mt.nodes {
+		n.l.RLock()
+		if n.RPC != nil {
+			ncs = append(ncs, conn.NodeConn{URL: n.URL, RPC: n.RPC})
+		}
+		n.l.RUnlock()
+	}
+	return ncs
+}
+
+func (mt *MultiTransport) do(ctx context.Context, height uint64, f func(ctx context.Context, c *ethclient.Client) error) (err error) {
+	return mt.doNode(ctx, height, func(ctx context.Context, n *Node) error {
+		return f(ctx, n.C)
+	})
+}
+
+// doNode calls nodes in order until one of them succeeds or fails with an error that is not retryable.
+// Each node is called with the attempt timeout, when all the nodes fail the call is retried by the policy.
+// Nodes with exhausted rate limit are skipped without counting as failed, calls ended by the caller's
+// context neither count as failures nor fail over.
+func (mt *MultiTransport) doNode(ctx context.Context, height uint64, f func(ctx context.Context, n *Node) error) (err error) {
+	return mt.call(ctx, height, false, f)
+}
+
+// call is doNode of batched calls when batched is set, which are charged to node's rate limit
+// by its batcher once per batch request, rather than one by one
+func (mt *MultiTransport) call(ctx context.Context, height uint64, batched bool, f func(ctx context.Context, n *Node) error) (err error) {
+	p := mt.Retry
+	p.AttemptTimeout = 0
+	return p.Do(ctx, func(ctx context.Context) (err error) {
+		nodes := mt.ordered(height)
+		if len(nodes) == 0 {
+			return ErrNoNodesAvailable
+		}
+
+		for _, n := range nodes {
+			if !n.breaker.Allow() {
+				continue
+			}
+			lim := n.limiter
+			if batched && n.B != nil {
+				lim = nil
+			}
+			if werr := lim.Wait(ctx); werr != nil {
+				if errors.Is(werr, conn.ErrQuotaExhausted) {
+					err = werr
+					continue
+				}
+				return werr
+			}
+			now := time.Now()
+			err = mt.Retry.Attempt(ctx, func(ctx context.Context) error {
+				return f(ctx, n)
+			})
+			if errors.Is(err, conn.ErrQuotaExhausted) { // batch rejected by node's rate limit
+				continue
+			}
+			if err == nil {
+				n.success(time.Since(now))
+				return nil
+			}
+			// the call ended by the caller is no failure of the node, nor a reason to call the others
+			if ctx.Err() != nil || !conn.IsRetryable(err) {
+				return err
+			}
+			if conn.CountFailure(err) { // failed batch request is one failure of the node
+				n.failure()
+			}
+			mt.log.Debug("Failing over ethereum node", zap.String("url", n.URL), zap.Error(err))
+		}
+		if err == nil { // all the nodes were refused by their breakers
+			return ErrNoNodesAvailable
+		}
+		return fmt.Errorf("all ethereum nodes failed: %w", err)
+	})
+}
+
+func minHeight(blockNumber *big.Int) uint64 {
+	if blockNumber == nil {
+		return 0
+	}
+	return blockNumber.Uint64()
+}
+
+type BoundContractC struct {
+	address common.Address
+	abi     abi.ABI
+	MT      *MultiTransport
+}
+
+func (bcc *BoundContractC) GetContract() *bind.BoundContract {
+	return bind.NewBoundContract(bcc.address, bcc.abi, bcc.MT, nil, nil)
+}
+
+func (bcc *BoundContractC) CallRaw(opts *bind.CallOpts, input []byte) ([]byte, error) {
+	msg := ethereum.CallMsg{From: opts.From, To: &bcc.address, Data: input}
+	if opts.Pending {
+		return bcc.MT.PendingCallContract(opts.Context, msg)
+	}
+	return bcc.MT.CallContract(opts.Context, msg, opts.BlockNumber)
+}
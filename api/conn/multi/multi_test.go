@@ -0,0 +1,121 @@
+package multi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"go.uber.org/zap"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+)
+
+// newTestTransport returns transport of dialed nodes, which clients are not connected
+func newTestTransport(nodes int) *MultiTransport {
+	urls := make([]string, nodes)
+	for i := range urls {
+		urls[i] = "ws://node" + string(rune('0'+i))
+	}
+	mt := NewMultiTransport(zap.NewNop(), urls)
+	for _, n := range mt.nodes {
+		n.C = ethclient.NewClient(nil)
+		n.breaker = conn.NewBreaker(mt.MaxFailures, time.Hour)
+	}
+	return mt
+}
+
+func TestHead(t *testing.T) {
+	header := func(n int64) *types.Header {
+		return &types.Header{Number: big.NewInt(n)}
+	}
+
+	mt := newTestTransport(2)
+	if h := mt.Head(); h != nil {
+		t.Errorf("head of not subscribed nodes = %v, want nil", h)
+	}
+
+	mt.nodes[0].setHeader(header(10))
+	mt.nodes[1].setHeader(header(11))
+	if h := mt.Head(); h == nil || h.Number.Int64() != 10 {
+		t.Errorf("head = %v, want 10 of the preferred node", h)
+	}
+	if mt.nodes[1].head != 11 {
+		t.Errorf("node head = %d, want 11", mt.nodes[1].head)
+	}
+
+	// calls are routed to the second node while breaker of the first one is open
+	for i := uint64(0); i < mt.MaxFailures; i++ {
+		mt.nodes[0].failure()
+	}
+	if h := mt.Head(); h == nil || h.Number.Int64() != 11 {
+		t.Errorf("head = %v, want 11 of the routed node", h)
+	}
+
+	// dropped subscription
+	mt.nodes[1].setHeader(nil)
+	if h := mt.Head(); h != nil {
+		t.Errorf("head = %v, want nil", h)
+	}
+}
+
+type revertError struct{}
+
+func (revertError) Error() string  { return "execution reverted" }
+func (revertError) ErrorCode() int { return 3 }
+
+type headerError struct{}
+
+func (headerError) Error() string  { return "header not found" }
+func (headerError) ErrorCode() int { return -32000 }
+
+func TestDoNodeFailover(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		want     error
+		calls    []string
+		failures uint64
+	}{
+		{name: "success", err: nil, want: nil, calls: []string{"ws://node0"}},
+		{name: "transient error fails over", err: syscall.ECONNRESET, want: nil, calls: []string{"ws://node0", "ws://node1"}, failures: 1},
+		{name: "contract error", err: revertError{}, want: revertError{}, calls: []string{"ws://node0"}},
+		{name: "node response", err: headerError{}, want: headerError{}, calls: []string{"ws://node0"}},
+		{name: "caller cancelled", err: context.Canceled, want: context.Canceled, calls: []string{"ws://node0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mt := newTestTransport(2)
+			mt.Retry = conn.RetryPolicy{MaxAttempts: 1}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			var calls []string
+			err := mt.doNode(ctx, 0, func(ctx context.Context, n *Node) error {
+				calls = append(calls, n.URL)
+				if n == mt.nodes[0] {
+					if errors.Is(tt.err, context.Canceled) {
+						cancel()
+					}
+					return tt.err
+				}
+				return nil
+			})
+			if err != tt.want {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+			if !reflect.DeepEqual(calls, tt.calls) {
+				t.Errorf("calls = %v, want %v", calls, tt.calls)
+			}
+			if f := mt.nodes[0].breaker.Failures(); f != tt.failures {
+				t.Errorf("failures = %d, want %d", f, tt.failures)
+			}
+		})
+	}
+}
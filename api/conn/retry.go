@@ -0,0 +1,136 @@
+package conn
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// DefaultCallTimeout bounds contract calls with no timeout configured
+const DefaultCallTimeout = 30 * time.Second
+
+// ErrNoNodesAvailable is returned when no node may be called, as none is dialed or all their circuit breakers are open
+var ErrNoNodesAvailable = errors.New("no ethereum nodes available")
+
+// rpcLimitExceeded is the error code providers return for exceeded request limits
+const rpcLimitExceeded = -32005
+
+// WithCallTimeout returns context bounded by timeout, DefaultCallTimeout is used when it is not set
+func WithCallTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		timeout = DefaultCallTimeout
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// RetryPolicy configures retries of node calls failing with transient errors
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first one, calls are not retried below 2
+	MaxAttempts int
+	// MinBackoff is the delay before the first retry, it doubles on every next one up to MaxBackoff, zero leaves it uncapped
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// Jitter is the fraction of the delay randomly taken off it, so retries of concurrent calls spread out
+	Jitter float64
+	// AttemptTimeout bounds a single attempt, zero leaves attempts bounded only by the call context
+	AttemptTimeout time.Duration
+}
+
+// DefaultRetryPolicy is the policy of transports with no policy configured
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	MinBackoff:     100 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+	Jitter:         0.5,
+	AttemptTimeout: 10 * time.Second,
+}
+
+// Backoff returns the delay before given retry, the first retry is 1
+func (p RetryPolicy) Backoff(retry int) time.Duration {
+	d := p.MinBackoff
+	for i := 1; i < retry && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if p.Jitter > 0 && d > 0 {
+		d -= time.Duration(rand.Float64() * p.Jitter * float64(d))
+	}
+	return d
+}
+
+// Do calls f until it succeeds, fails with an error that is not retryable or attempts run out.
+// Attempt timing out on AttemptTimeout is retried as long as the call context is not done.
+func (p RetryPolicy) Do(ctx context.Context, f func(ctx context.Context) error) (err error) {
+	for attempt := 1; ; attempt++ {
+		err = p.Attempt(ctx, f)
+		if err == nil || attempt >= p.MaxAttempts || ctx.Err() != nil {
+			return err
+		}
+		if !IsRetryable(err) && !errors.Is(err, context.DeadlineExceeded) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(p.Backoff(attempt)):
+		}
+	}
+}
+
+// Attempt calls f once, bounded by AttemptTimeout
+func (p RetryPolicy) Attempt(ctx context.Context, f func(ctx context.Context) error) error {
+	if p.AttemptTimeout <= 0 {
+		return f(ctx)
+	}
+	ctxT, cancel := context.WithTimeout(ctx, p.AttemptTimeout)
+	defer cancel()
+	return f(ctxT)
+}
+
+// IsRetryable reports if error is transient, so the call may succeed when repeated. These are connection
+// errors, timeouts, HTTP 408, 429 and 5xx gateway errors and provider's limit exceeded errors.
+// Contract errors and other node responses are not retried.
+func IsRetryable(err error) bool {
+	if err == nil || IsContractError(err) {
+		return false
+	}
+
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		msg := strings.ToLower(rpcErr.Error())
+		return rpcErr.ErrorCode() == rpcLimitExceeded || strings.Contains(msg, "rate limit") || strings.Contains(msg, "too many requests")
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// IsUnavailable reports if error means the node can not serve the call right now, rather than the call is invalid
+func IsUnavailable(err error) bool {
+	return errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrNoNodesAvailable) || IsRetryable(err)
+}
@@ -0,0 +1,194 @@
+package conn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// rpcError is an error response of the node
+type rpcError struct {
+	code int
+	msg  string
+}
+
+func (e rpcError) Error() string  { return e.msg }
+func (e rpcError) ErrorCode() int { return e.code }
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		retry    int
+		min, max time.Duration
+	}{
+		{
+			name:   "first retry",
+			policy: RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second},
+			retry:  1,
+			min:    100 * time.Millisecond,
+			max:    100 * time.Millisecond,
+		}, {
+			name:   "doubles",
+			policy: RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second},
+			retry:  3,
+			min:    400 * time.Millisecond,
+			max:    400 * time.Millisecond,
+		}, {
+			name:   "capped",
+			policy: RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second},
+			retry:  10,
+			min:    time.Second,
+			max:    time.Second,
+		}, {
+			name:   "no cap",
+			policy: RetryPolicy{MinBackoff: 100 * time.Millisecond},
+			retry:  4,
+			min:    800 * time.Millisecond,
+			max:    800 * time.Millisecond,
+		}, {
+			name:   "jitter",
+			policy: RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Jitter: 0.5},
+			retry:  2,
+			min:    100 * time.Millisecond,
+			max:    200 * time.Millisecond,
+		}, {
+			name:   "capped with jitter",
+			policy: RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Jitter: 0.5},
+			retry:  10,
+			min:    500 * time.Millisecond,
+			max:    time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				if d := tt.policy.Backoff(tt.retry); d < tt.min || d > tt.max {
+					t.Fatalf("backoff = %s, want between %s and %s", d, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryDo(t *testing.T) {
+	transient := syscall.ECONNRESET
+	policy := RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		errs     []error
+		attempts int
+		err      error
+	}{
+		{
+			name:     "success",
+			policy:   policy,
+			errs:     []error{nil},
+			attempts: 1,
+		}, {
+			name:     "transient error retried",
+			policy:   policy,
+			errs:     []error{transient, transient, nil},
+			attempts: 3,
+		}, {
+			name:     "attempts run out",
+			policy:   policy,
+			errs:     []error{transient, transient, transient, nil},
+			attempts: 3,
+			err:      transient,
+		}, {
+			name:     "error not retryable",
+			policy:   policy,
+			errs:     []error{rpcError{code: 3, msg: "execution reverted"}, nil},
+			attempts: 1,
+			err:      rpcError{code: 3, msg: "execution reverted"},
+		}, {
+			name:     "single attempt",
+			policy:   RetryPolicy{MaxAttempts: 1},
+			errs:     []error{transient, nil},
+			attempts: 1,
+			err:      transient,
+		}, {
+			name:     "attempt timeout retried",
+			policy:   RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond, AttemptTimeout: 10 * time.Millisecond},
+			errs:     []error{context.DeadlineExceeded, nil},
+			attempts: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int
+			err := tt.policy.Do(context.Background(), func(ctx context.Context) error {
+				err := tt.errs[attempts]
+				attempts++
+				if errors.Is(err, context.DeadlineExceeded) {
+					<-ctx.Done()
+					return ctx.Err()
+				}
+				return err
+			})
+			if err != tt.err {
+				t.Errorf("error = %v, want %v", err, tt.err)
+			}
+			if attempts != tt.attempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestRetryDoCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := RetryPolicy{MaxAttempts: 5, MinBackoff: time.Hour}
+
+	var attempts int
+	err := policy.Do(ctx, func(ctx context.Context) error {
+		attempts++
+		cancel()
+		return io.EOF
+	})
+	if err != io.EOF || attempts != 1 {
+		t.Errorf("error = %v after %d attempts, want %v after 1", err, attempts, io.EOF)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "contract error", err: rpcError{code: 3, msg: "execution reverted"}, want: false},
+		{name: "http 429", err: rpc.HTTPError{StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "http 502", err: rpc.HTTPError{StatusCode: http.StatusBadGateway}, want: true},
+		{name: "http 400", err: rpc.HTTPError{StatusCode: http.StatusBadRequest}, want: false},
+		{name: "limit exceeded code", err: rpcError{code: rpcLimitExceeded, msg: "limit"}, want: true},
+		{name: "rate limit message", err: rpcError{code: -32000, msg: "Rate limit reached"}, want: true},
+		{name: "other node error", err: rpcError{code: -32000, msg: "header not found"}, want: false},
+		{name: "connection reset", err: fmt.Errorf("post: %w", syscall.ECONNRESET), want: true},
+		{name: "connection refused", err: syscall.ECONNREFUSED, want: true},
+		{name: "unexpected eof", err: io.ErrUnexpectedEOF, want: true},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, want: true},
+		{name: "cancelled", err: context.Canceled, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package erc1155
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+	"github.com/figment-networks/ethereum-worker/api/erc165"
+	"github.com/figment-networks/ethereum-worker/api/erc20"
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+// InterfaceERC1155 is ERC1155 ERC165 interface id
+var InterfaceERC1155 = [4]byte{0xd9, 0xb6, 0x7a, 0x26}
+
+type ERC1155Caller struct {
+	NodeType erc20.EthereumNodeType
+	// Timeout bounds every call including its retries, conn.DefaultCallTimeout when not set
+	Timeout time.Duration
+}
+
+func (c *ERC1155Caller) callOpts(ctx context.Context, bs structures.BlockSelector) (*bind.CallOpts, error) {
+	if c.NodeType != erc20.ENTArchive {
+		bs = structures.LatestBlock
+	}
+	return conn.CallOpts(ctx, bs)
+}
+
+func (c *ERC1155Caller) BalanceOf(ctx context.Context, bc *bind.BoundContract, owner common.Address, id *big.Int, bs structures.BlockSelector) (balance big.Int, err error) {
+	ctxT, cancel := conn.WithCallTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	co, err := c.callOpts(ctxT, bs)
+	if err != nil {
+		return balance, err
+	}
+
+	results := []interface{}{}
+	err = bc.Call(co, &results, "balanceOf", owner, id)
+	if err != nil {
+		return balance, fmt.Errorf("error calling balanceOf function %w", err)
+	}
+
+	if len(results) == 0 {
+		return balance, errors.New("empty result")
+	}
+
+	b, ok := results[0].(*big.Int)
+	if !ok {
+		return balance, errors.New("balance is not *big.Int type")
+	}
+
+	return *b, nil
+}
+
+// BalanceOfBatch returns balances of owners[i] for ids[i]
+func (c *ERC1155Caller) BalanceOfBatch(ctx context.Context, bc *bind.BoundContract, owners []common.Address, ids []*big.Int, bs structures.BlockSelector) (balances []*big.Int, err error) {
+	if len(owners) != len(ids) {
+		return nil, errors.New("owners and ids have to be of the same length")
+	}
+
+	ctxT, cancel := conn.WithCallTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	co, err := c.callOpts(ctxT, bs)
+	if err != nil {
+		return balances, err
+	}
+
+	results := []interface{}{}
+	err = bc.Call(co, &results, "balanceOfBatch", owners, ids)
+	if err != nil {
+		return nil, fmt.Errorf("error calling balanceOfBatch function %w", err)
+	}
+
+	if len(results) == 0 {
+		return nil, errors.New("empty result")
+	}
+
+	b, ok := results[0].([]*big.Int)
+	if !ok {
+		return nil, errors.New("balances are not []*big.Int type")
+	}
+
+	if len(b) != len(ids) {
+		return nil, fmt.Errorf("expected %d balances, got %d", len(ids), len(b))
+	}
+
+	return b, nil
+}
+
+func (c *ERC1155Caller) URI(ctx context.Context, bc *bind.BoundContract, id *big.Int, bs structures.BlockSelector) (uri string, err error) {
+	ctxT, cancel := conn.WithCallTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	co, err := c.callOpts(ctxT, bs)
+	if err != nil {
+		return uri, err
+	}
+
+	results := []interface{}{}
+	err = bc.Call(co, &results, "uri", id)
+	if err != nil {
+		return uri, fmt.Errorf("error calling uri function %w", err)
+	}
+
+	if len(results) == 0 {
+		return uri, errors.New("empty result")
+	}
+
+	u, ok := results[0].(string)
+	if !ok {
+		return uri, errors.New("uri is not a string type")
+	}
+
+	return u, nil
+}
+
+// SupportsInterface checks ERC165 interface support. Contracts not implementing ERC165 report false.
+func (c *ERC1155Caller) SupportsInterface(ctx context.Context, bcc conn.BoundContractCaller, interfaceID [4]byte, bs structures.BlockSelector) (supported bool, err error) {
+	ctxT, cancel := conn.WithCallTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	co, err := c.callOpts(ctxT, bs)
+	if err != nil {
+		return false, err
+	}
+
+	return erc165.SupportsInterface(bcc, co, interfaceID)
+}
@@ -0,0 +1,34 @@
+package erc165
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+)
+
+// supportsInterfaceSelector is supportsInterface(bytes4) selector
+var supportsInterfaceSelector = []byte{0x01, 0xff, 0xc9, 0xa7}
+
+// SupportsInterface checks ERC165 interface support. Contracts not implementing ERC165 report false.
+func SupportsInterface(bcc conn.BoundContractCaller, co *bind.CallOpts, interfaceID [4]byte) (supported bool, err error) {
+	input := make([]byte, 36)
+	copy(input, supportsInterfaceSelector)
+	copy(input[4:], interfaceID[:])
+
+	out, err := bcc.CallRaw(co, input)
+	if err != nil {
+		if conn.IsContractError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error calling supportsInterface function %w", err)
+	}
+
+	// non ERC165 contracts may return anything from their fallback function
+	if len(out) != 32 {
+		return false, nil
+	}
+	return new(big.Int).SetBytes(out).Cmp(big.NewInt(1)) == 0, nil
+}
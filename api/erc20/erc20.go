@@ -9,6 +9,9 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+	"github.com/figment-networks/ethereum-worker/structures"
 )
 
 type EthereumNodeType uint8
@@ -20,22 +23,24 @@ const (
 
 type ERC20Caller struct {
 	NodeType EthereumNodeType
+	// Timeout bounds every call including its retries, conn.DefaultCallTimeout when not set
+	Timeout time.Duration
 }
 
-func (c *ERC20Caller) TotalSupply(ctx context.Context, bc *bind.BoundContract, blockNumber uint64) (ts big.Int, err error) {
-	ctxT, cancel := context.WithTimeout(ctx, time.Second*30)
-	defer cancel()
-
-	co := &bind.CallOpts{
-		Context: ctxT,
+func (c *ERC20Caller) callOpts(ctx context.Context, bs structures.BlockSelector) (*bind.CallOpts, error) {
+	if c.NodeType != ENTArchive {
+		bs = structures.LatestBlock
 	}
+	return conn.CallOpts(ctx, bs)
+}
 
-	if c.NodeType == ENTArchive {
-		if blockNumber > 0 { // (lukanus): 0 = latest
-			co.BlockNumber = new(big.Int).SetUint64(blockNumber)
-		} else {
-			co.Pending = true
-		}
+func (c *ERC20Caller) TotalSupply(ctx context.Context, bc *bind.BoundContract, bs structures.BlockSelector) (ts big.Int, err error) {
+	ctxT, cancel := conn.WithCallTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	co, err := c.callOpts(ctxT, bs)
+	if err != nil {
+		return ts, err
 	}
 
 	results := []interface{}{}
@@ -57,20 +62,13 @@ func (c *ERC20Caller) TotalSupply(ctx context.Context, bc *bind.BoundContract, b
 	return *b, nil
 }
 
-func (c *ERC20Caller) BalanceOf(ctx context.Context, bc *bind.BoundContract, tokenHolder common.Address, blockNumber uint64) (balance big.Int, err error) {
-	ctxT, cancel := context.WithTimeout(ctx, time.Second*30)
+func (c *ERC20Caller) BalanceOf(ctx context.Context, bc *bind.BoundContract, tokenHolder common.Address, bs structures.BlockSelector) (balance big.Int, err error) {
+	ctxT, cancel := conn.WithCallTimeout(ctx, c.Timeout)
 	defer cancel()
 
-	co := &bind.CallOpts{
-		Context: ctxT,
-	}
-
-	if c.NodeType == ENTArchive {
-		if blockNumber > 0 { // (lukanus): 0 = latest
-			co.BlockNumber = new(big.Int).SetUint64(blockNumber)
-		} else {
-			co.Pending = true
-		}
+	co, err := c.callOpts(ctxT, bs)
+	if err != nil {
+		return balance, err
 	}
 
 	results := []interface{}{}
@@ -91,20 +89,13 @@ func (c *ERC20Caller) BalanceOf(ctx context.Context, bc *bind.BoundContract, tok
 	return *b, nil
 }
 
-func (c *ERC20Caller) Transfer(ctx context.Context, bc *bind.BoundContract, blockNumber uint64, recipient common.Address, amount *big.Int) (successful bool, err error) {
-	ctxT, cancel := context.WithTimeout(ctx, time.Second*30)
+func (c *ERC20Caller) Transfer(ctx context.Context, bc *bind.BoundContract, bs structures.BlockSelector, recipient common.Address, amount *big.Int) (successful bool, err error) {
+	ctxT, cancel := conn.WithCallTimeout(ctx, c.Timeout)
 	defer cancel()
 
-	co := &bind.CallOpts{
-		Context: ctxT,
-	}
-
-	if c.NodeType == ENTArchive {
-		if blockNumber > 0 { // (lukanus): 0 = latest
-			co.BlockNumber = new(big.Int).SetUint64(blockNumber)
-		} else {
-			co.Pending = true
-		}
+	co, err := c.callOpts(ctxT, bs)
+	if err != nil {
+		return successful, err
 	}
 
 	results := []interface{}{}
@@ -126,20 +117,13 @@ func (c *ERC20Caller) Transfer(ctx context.Context, bc *bind.BoundContract, bloc
 	return successful, nil
 }
 
-func (c *ERC20Caller) Allowance(ctx context.Context, bc *bind.BoundContract, blockNumber uint64, owner, spender common.Address) (res big.Int, err error) {
-	ctxT, cancel := context.WithTimeout(ctx, time.Second*30)
+func (c *ERC20Caller) Allowance(ctx context.Context, bc *bind.BoundContract, bs structures.BlockSelector, owner, spender common.Address) (res big.Int, err error) {
+	ctxT, cancel := conn.WithCallTimeout(ctx, c.Timeout)
 	defer cancel()
 
-	co := &bind.CallOpts{
-		Context: ctxT,
-	}
-
-	if c.NodeType == ENTArchive {
-		if blockNumber > 0 { // (lukanus): 0 = latest
-			co.BlockNumber = new(big.Int).SetUint64(blockNumber)
-		} else {
-			co.Pending = true
-		}
+	co, err := c.callOpts(ctxT, bs)
+	if err != nil {
+		return res, err
 	}
 
 	results := []interface{}{}
@@ -161,20 +145,13 @@ func (c *ERC20Caller) Allowance(ctx context.Context, bc *bind.BoundContract, blo
 	return *a, nil
 }
 
-func (c *ERC20Caller) Approve(ctx context.Context, bc *bind.BoundContract, blockNumber uint64, spender common.Address, amount *big.Int) (successful bool, err error) {
-	ctxT, cancel := context.WithTimeout(ctx, time.Second*30)
+func (c *ERC20Caller) Approve(ctx context.Context, bc *bind.BoundContract, bs structures.BlockSelector, spender common.Address, amount *big.Int) (successful bool, err error) {
+	ctxT, cancel := conn.WithCallTimeout(ctx, c.Timeout)
 	defer cancel()
 
-	co := &bind.CallOpts{
-		Context: ctxT,
-	}
-
-	if c.NodeType == ENTArchive {
-		if blockNumber > 0 { // (lukanus): 0 = latest
-			co.BlockNumber = new(big.Int).SetUint64(blockNumber)
-		} else {
-			co.Pending = true
-		}
+	co, err := c.callOpts(ctxT, bs)
+	if err != nil {
+		return successful, err
 	}
 
 	results := []interface{}{}
@@ -196,20 +173,13 @@ func (c *ERC20Caller) Approve(ctx context.Context, bc *bind.BoundContract, block
 	return successful, nil
 }
 
-func (c *ERC20Caller) TransferFrom(ctx context.Context, bc *bind.BoundContract, blockNumber uint64, sender, recipient common.Address, amount *big.Int) (successful bool, err error) {
-	ctxT, cancel := context.WithTimeout(ctx, time.Second*30)
+func (c *ERC20Caller) TransferFrom(ctx context.Context, bc *bind.BoundContract, bs structures.BlockSelector, sender, recipient common.Address, amount *big.Int) (successful bool, err error) {
+	ctxT, cancel := conn.WithCallTimeout(ctx, c.Timeout)
 	defer cancel()
 
-	co := &bind.CallOpts{
-		Context: ctxT,
-	}
-
-	if c.NodeType == ENTArchive {
-		if blockNumber > 0 { // (lukanus): 0 = latest
-			co.BlockNumber = new(big.Int).SetUint64(blockNumber)
-		} else {
-			co.Pending = true
-		}
+	co, err := c.callOpts(ctxT, bs)
+	if err != nil {
+		return successful, err
 	}
 
 	results := []interface{}{}
@@ -231,97 +201,21 @@ func (c *ERC20Caller) TransferFrom(ctx context.Context, bc *bind.BoundContract,
 	return successful, nil
 }
 
-func (c *ERC20Caller) Name(ctx context.Context, bc *bind.BoundContract, blockNumber uint64) (name string, err error) {
-	ctxT, cancel := context.WithTimeout(ctx, time.Second*30)
+// Decimals reads decimals of a contract returning standard uint8, Metadata also decodes other integer outputs
+func (c *ERC20Caller) Decimals(ctx context.Context, bc *bind.BoundContract, bs structures.BlockSelector) (res uint64, err error) {
+	ctxT, cancel := conn.WithCallTimeout(ctx, c.Timeout)
 	defer cancel()
 
-	co := &bind.CallOpts{
-		Context: ctxT,
-	}
-
-	if c.NodeType == ENTArchive {
-		if blockNumber > 0 { // (lukanus): 0 = latest
-			co.BlockNumber = new(big.Int).SetUint64(blockNumber)
-		} else {
-			co.Pending = true
-		}
-	}
-
-	results := []interface{}{}
-	err = bc.Call(co, &results, "name")
-
+	co, err := c.callOpts(ctxT, bs)
 	if err != nil {
-		return name, fmt.Errorf("error calling name function %w", err)
-	}
-
-	if len(results) == 0 {
-		return name, errors.New("empty result")
-	}
-
-	n, ok := results[0].(string)
-	if !ok {
-		return name, errors.New("name is not a string type")
-	}
-
-	return n, nil
-}
-
-func (c *ERC20Caller) Symbol(ctx context.Context, bc *bind.BoundContract, blockNumber uint64) (symbol string, err error) {
-	ctxT, cancel := context.WithTimeout(ctx, time.Second*30)
-	defer cancel()
-
-	co := &bind.CallOpts{
-		Context: ctxT,
-	}
-
-	if c.NodeType == ENTArchive {
-		if blockNumber > 0 { // (lukanus): 0 = latest
-			co.BlockNumber = new(big.Int).SetUint64(blockNumber)
-		} else {
-			co.Pending = true
-		}
-	}
-
-	results := []interface{}{}
-	err = bc.Call(co, &results, "symbol")
-
-	if err != nil {
-		return symbol, fmt.Errorf("error calling symbol function %w", err)
-	}
-
-	if len(results) == 0 {
-		return symbol, errors.New("empty result")
-	}
-
-	n, ok := results[0].(string)
-	if !ok {
-		return symbol, errors.New("symbol is not a string type")
-	}
-
-	return n, nil
-}
-
-func (c *ERC20Caller) Decimals(ctx context.Context, bc *bind.BoundContract, blockNumber uint64) (res uint64, err error) {
-	ctxT, cancel := context.WithTimeout(ctx, time.Second*30)
-	defer cancel()
-
-	co := &bind.CallOpts{
-		Context: ctxT,
-	}
-
-	if c.NodeType == ENTArchive {
-		if blockNumber > 0 { // (lukanus): 0 = latest
-			co.BlockNumber = new(big.Int).SetUint64(blockNumber)
-		} else {
-			co.Pending = true
-		}
+		return res, err
 	}
 
 	results := []interface{}{}
 	err = bc.Call(co, &results, "decimals")
 
 	if err != nil {
-		return res, fmt.Errorf("error calling allowance function %w", err)
+		return res, fmt.Errorf("error calling decimals function %w", err)
 	}
 
 	if len(results) == 0 {
@@ -330,7 +224,7 @@ func (c *ERC20Caller) Decimals(ctx context.Context, bc *bind.BoundContract, bloc
 
 	a, ok := results[0].(uint8)
 	if !ok {
-		return res, errors.New("balance is not *big.Int type")
+		return res, errors.New("decimals is not uint8 type")
 	}
 
 	return uint64(a), nil
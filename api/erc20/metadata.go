@@ -0,0 +1,154 @@
+package erc20
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+// Names of metadata fields reported in structures.Details Unavailable
+const (
+	FieldName     = "name"
+	FieldSymbol   = "symbol"
+	FieldDecimals = "decimals"
+)
+
+var (
+	nameSelector     = []byte{0x06, 0xfd, 0xde, 0x03} // name()
+	symbolSelector   = []byte{0x95, 0xd8, 0x9b, 0x41} // symbol()
+	decimalsSelector = []byte{0x31, 0x3c, 0xe5, 0x67} // decimals()
+)
+
+var (
+	stringArgs  = mustArguments("string")
+	bytes32Args = mustArguments("bytes32")
+	uint256Args = mustArguments("uint256")
+)
+
+func mustArguments(t string) abi.Arguments {
+	typ, err := abi.NewType(t, "", nil)
+	if err != nil {
+		panic(err)
+	}
+	return abi.Arguments{{Type: typ}}
+}
+
+// Metadata returns token name, symbol and decimals. Fields that contract does not implement,
+// reverts on or returns in undecodable form are listed in Unavailable instead of failing the whole call.
+func (c *ERC20Caller) Metadata(ctx context.Context, bcc conn.BoundContractCaller, bs structures.BlockSelector) (det structures.Details, err error) {
+	out, err := c.callMetadata(ctx, bcc, bs, nameSelector)
+	if err != nil {
+		return det, err
+	}
+	name, ok := DecodeString(out)
+	if !ok {
+		det.Unavailable = append(det.Unavailable, FieldName)
+	}
+	det.Name = name
+
+	if out, err = c.callMetadata(ctx, bcc, bs, symbolSelector); err != nil {
+		return det, err
+	}
+	symbol, ok := DecodeString(out)
+	if !ok {
+		det.Unavailable = append(det.Unavailable, FieldSymbol)
+	}
+	det.Symbol = symbol
+
+	if out, err = c.callMetadata(ctx, bcc, bs, decimalsSelector); err != nil {
+		return det, err
+	}
+	decimals, ok := DecodeDecimals(out)
+	if !ok {
+		det.Unavailable = append(det.Unavailable, FieldDecimals)
+	}
+	det.Decimals = decimals
+
+	return det, nil
+}
+
+// callMetadata returns raw output of the call. Contract errors are not returned,
+// as they only mean the field is not available.
+func (c *ERC20Caller) callMetadata(ctx context.Context, bcc conn.BoundContractCaller, bs structures.BlockSelector, selector []byte) ([]byte, error) {
+	ctxT, cancel := conn.WithCallTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	co, err := c.callOpts(ctxT, bs)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := bcc.CallRaw(co, selector)
+	if err != nil {
+		if conn.IsContractError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// DecodeString decodes name or symbol output. It tries ABI string first,
+// then bytes32 (used by e.g. MKR and SAI) and finally raw utf-8 bytes.
+func DecodeString(out []byte) (string, bool) {
+	if len(out) == 0 {
+		return "", false
+	}
+
+	// ABI string is at least its offset and length words
+	if v, err := stringArgs.Unpack(out); err == nil && len(v) > 0 && len(out) >= 64 {
+		if s, ok := v[0].(string); ok {
+			return s, true
+		}
+	}
+
+	if v, err := bytes32Args.Unpack(out); err == nil && len(v) > 0 && len(out) == 32 {
+		if b, ok := v[0].([32]byte); ok {
+			if s, ok := trimmedString(b[:]); ok {
+				return s, true
+			}
+		}
+	}
+
+	return trimmedString(out)
+}
+
+// trimmedString decodes zero padded utf-8 bytes, strings with non-printable characters are not names
+func trimmedString(b []byte) (string, bool) {
+	s := strings.TrimSpace(string(bytes.Trim(b, "\x00")))
+	if s == "" || !utf8.ValidString(s) {
+		return "", false
+	}
+	for _, r := range s {
+		if !unicode.IsPrint(r) {
+			return "", false
+		}
+	}
+	return s, true
+}
+
+// DecodeDecimals decodes decimals output, accepting any uint word that fits uint8
+func DecodeDecimals(out []byte) (uint64, bool) {
+	if len(out) == 0 {
+		return 0, false
+	}
+
+	v, err := uint256Args.Unpack(out)
+	if err != nil || len(v) == 0 {
+		return 0, false
+	}
+
+	d, ok := v[0].(*big.Int)
+	if !ok || !d.IsUint64() || d.Uint64() > 255 {
+		return 0, false
+	}
+	return d.Uint64(), true
+}
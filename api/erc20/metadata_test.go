@@ -0,0 +1,159 @@
+package erc20
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+func bytes32(s string) []byte {
+	b := make([]byte, 32)
+	copy(b, s)
+	return b
+}
+
+func TestDecodeString(t *testing.T) {
+	tests := []struct {
+		name string
+		out  []byte
+		want string
+		ok   bool
+	}{
+		{name: "abi string", out: pack(t, "string", "Token"), want: "Token", ok: true},
+		{name: "empty abi string", out: pack(t, "string", ""), want: "", ok: true},
+		{name: "bytes32", out: bytes32("Maker"), want: "Maker", ok: true},
+		{name: "bytes32 with spaces", out: bytes32(" SAI  "), want: "SAI", ok: true},
+		{name: "raw bytes", out: []byte("DAI"), want: "DAI", ok: true},
+		{name: "no output", out: nil},
+		{name: "zero bytes32", out: make([]byte, 32)},
+		{name: "invalid utf-8", out: bytes32("\xff\xfe")},
+		{name: "control characters", out: bytes32("\x01")},
+		{name: "control character inside name", out: []byte("Tok\x07en")},
+		{name: "whitespace only", out: []byte(" \t ")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := DecodeString(tt.out)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("DecodeString() = %q, %v, want %q, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestDecodeDecimals(t *testing.T) {
+	tests := []struct {
+		name string
+		out  []byte
+		want uint64
+		ok   bool
+	}{
+		{name: "uint8", out: pack(t, "uint256", big.NewInt(18)), want: 18, ok: true},
+		{name: "zero", out: pack(t, "uint256", big.NewInt(0)), want: 0, ok: true},
+		{name: "max uint8", out: pack(t, "uint256", big.NewInt(255)), want: 255, ok: true},
+		{name: "over uint8", out: pack(t, "uint256", big.NewInt(256))},
+		{name: "no output", out: nil},
+		{name: "short output", out: []byte{0x12}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := DecodeDecimals(tt.out)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("DecodeDecimals() = %d, %v, want %d, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+type rpcError struct {
+	code int
+	msg  string
+}
+
+func (e rpcError) Error() string  { return e.msg }
+func (e rpcError) ErrorCode() int { return e.code }
+
+// metadataCaller answers raw calls by their selector
+type metadataCaller struct {
+	outs map[string][]byte
+	errs map[string]error
+}
+
+func (mc metadataCaller) GetContract() *bind.BoundContract { return nil }
+
+func (mc metadataCaller) CallRaw(opts *bind.CallOpts, input []byte) ([]byte, error) {
+	for name, selector := range map[string][]byte{"name": nameSelector, "symbol": symbolSelector, "decimals": decimalsSelector} {
+		if bytes.Equal(input, selector) {
+			return mc.outs[name], mc.errs[name]
+		}
+	}
+	return nil, errors.New("unknown selector")
+}
+
+func TestMetadata(t *testing.T) {
+	revert := rpcError{code: 3, msg: "execution reverted"}
+	outs := map[string][]byte{
+		"name":     pack(t, "string", "Token"),
+		"symbol":   bytes32("TKN"),
+		"decimals": pack(t, "uint256", big.NewInt(18)),
+	}
+
+	tests := []struct {
+		name    string
+		caller  metadataCaller
+		want    structures.Details
+		wantErr bool
+	}{
+		{
+			name:   "string and bytes32 fields",
+			caller: metadataCaller{outs: outs},
+			want:   structures.Details{Name: "Token", Symbol: "TKN", Decimals: 18},
+		}, {
+			name:   "reverted name",
+			caller: metadataCaller{outs: outs, errs: map[string]error{"name": revert}},
+			want:   structures.Details{Symbol: "TKN", Decimals: 18, Unavailable: []string{FieldName}},
+		}, {
+			name: "missing symbol and decimals",
+			caller: metadataCaller{outs: map[string][]byte{"name": outs["name"]}, errs: map[string]error{
+				"symbol":   revert,
+				"decimals": rpcError{code: -32000, msg: "execution reverted: not implemented"},
+			}},
+			want: structures.Details{Name: "Token", Unavailable: []string{FieldSymbol, FieldDecimals}},
+		}, {
+			name:   "non-printable symbol",
+			caller: metadataCaller{outs: map[string][]byte{"name": outs["name"], "symbol": bytes32("\x01"), "decimals": outs["decimals"]}},
+			want:   structures.Details{Name: "Token", Decimals: 18, Unavailable: []string{FieldSymbol}},
+		}, {
+			name:    "node error",
+			caller:  metadataCaller{outs: outs, errs: map[string]error{"decimals": rpcError{code: -32000, msg: "header not found"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			det, err := (&ERC20Caller{}).Metadata(context.Background(), tt.caller, structures.LatestBlock)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(det, tt.want) {
+				t.Errorf("Metadata() = %+v, want %+v", det, tt.want)
+			}
+		})
+	}
+}
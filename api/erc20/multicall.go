@@ -0,0 +1,208 @@
+package erc20
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+// Multicall3Address is the address Multicall3 is deployed at on most of the EVM chains
+const Multicall3Address = "0xcA11bde05977b3631167028862bE2a173976CA11"
+
+var (
+	ErrMulticallNotDeployed = errors.New("multicall contract is not deployed")
+	// ErrMulticallDecode is returned when aggregate3 result does not match the calls
+	ErrMulticallDecode = errors.New("unexpected aggregate3 result")
+)
+
+// Call3 is a single call aggregated by Multicall3 aggregate3
+type Call3 struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// Result is a result of a single aggregate3 call
+type Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// TokenData is a set of ERC20 values fetched in one multicall. Details fields of failed calls
+// are listed as unavailable, Balance and TotalSupply are nil when their calls failed.
+type TokenData struct {
+	Details     structures.Details
+	Balance     *big.Int
+	TotalSupply *big.Int
+}
+
+// Multicall aggregates ERC20 calls into a single Multicall3 aggregate3 call
+type Multicall struct {
+	NodeType EthereumNodeType
+	// Timeout bounds every call including its retries, conn.DefaultCallTimeout when not set
+	Timeout time.Duration
+
+	erc20ABI abi.ABI
+
+	l                sync.RWMutex
+	unavailable      bool
+	notDeployedBelow uint64
+}
+
+// NewMulticall is Multicall constructor
+func NewMulticall(erc20ABI abi.ABI) *Multicall {
+	return &Multicall{erc20ABI: erc20ABI}
+}
+
+// Available reports whether multicall contract may exist at given block.
+// It returns false for heights it has already been found missing at.
+func (m *Multicall) Available(bs structures.BlockSelector) bool {
+	m.l.RLock()
+	defer m.l.RUnlock()
+
+	if m.unavailable {
+		return false
+	}
+	return bs.Tag != structures.BlockNumber || bs.Number > m.notDeployedBelow
+}
+
+func (m *Multicall) markNotDeployed(bs structures.BlockSelector) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if bs.Tag != structures.BlockNumber {
+		m.unavailable = true
+		return
+	}
+	if bs.Number > m.notDeployedBelow {
+		m.notDeployedBelow = bs.Number
+	}
+}
+
+// Aggregate3 calls aggregate3 on the multicall contract
+func (m *Multicall) Aggregate3(ctx context.Context, mc *bind.BoundContract, bs structures.BlockSelector, calls []Call3) (res []Result, err error) {
+	ctxT, cancel := conn.WithCallTimeout(ctx, m.Timeout)
+	defer cancel()
+
+	if m.NodeType != ENTArchive {
+		bs = structures.LatestBlock
+	}
+	co, err := conn.CallOpts(ctxT, bs)
+	if err != nil {
+		return res, err
+	}
+
+	results := []interface{}{}
+	err = mc.Call(co, &results, "aggregate3", calls)
+	if err != nil {
+		if errors.Is(err, bind.ErrNoCode) {
+			m.markNotDeployed(bs)
+			return res, ErrMulticallNotDeployed
+		}
+		return res, fmt.Errorf("error calling aggregate3 function %w", err)
+	}
+
+	if len(results) == 0 {
+		return res, fmt.Errorf("%w: empty result", ErrMulticallDecode)
+	}
+
+	converted, ok := abi.ConvertType(results[0], new([]Result)).(*[]Result)
+	if !ok || converted == nil {
+		return nil, fmt.Errorf("%w: result is not []Result type", ErrMulticallDecode)
+	}
+
+	if len(*converted) != len(calls) {
+		return nil, fmt.Errorf("%w: expected %d results, got %d", ErrMulticallDecode, len(calls), len(*converted))
+	}
+
+	return *converted, nil
+}
+
+// TokenData fetches token details together with holder's balance (when holder is set)
+// and total supply (when totalSupply is true) in a single aggregate3 call.
+func (m *Multicall) TokenData(ctx context.Context, mc *bind.BoundContract, token common.Address, holder *common.Address, totalSupply bool, bs structures.BlockSelector) (td TokenData, err error) {
+	methods := []string{"name", "symbol", "decimals"}
+	args := [][]interface{}{nil, nil, nil}
+	if holder != nil {
+		methods = append(methods, "balanceOf")
+		args = append(args, []interface{}{*holder})
+	}
+	if totalSupply {
+		methods = append(methods, "totalSupply")
+		args = append(args, nil)
+	}
+
+	calls := make([]Call3, len(methods))
+	for i, method := range methods {
+		calls[i] = Call3{Target: token, AllowFailure: true}
+		if calls[i].CallData, err = m.erc20ABI.Pack(method, args[i]...); err != nil {
+			return td, fmt.Errorf("error packing %s call: %w", method, err)
+		}
+	}
+
+	res, err := m.Aggregate3(ctx, mc, bs, calls)
+	if err != nil {
+		return td, err
+	}
+
+	for i, method := range methods {
+		var ok bool
+		switch method {
+		case "name":
+			if res[i].Success {
+				td.Details.Name, ok = DecodeString(res[i].ReturnData)
+			}
+			if !ok {
+				td.Details.Name = ""
+				td.Details.Unavailable = append(td.Details.Unavailable, FieldName)
+			}
+			continue
+		case "symbol":
+			if res[i].Success {
+				td.Details.Symbol, ok = DecodeString(res[i].ReturnData)
+			}
+			if !ok {
+				td.Details.Symbol = ""
+				td.Details.Unavailable = append(td.Details.Unavailable, FieldSymbol)
+			}
+			continue
+		case "decimals":
+			if res[i].Success {
+				td.Details.Decimals, ok = DecodeDecimals(res[i].ReturnData)
+			}
+			if !ok {
+				td.Details.Decimals = 0
+				td.Details.Unavailable = append(td.Details.Unavailable, FieldDecimals)
+			}
+			continue
+		}
+
+		// failed call leaves the value nil, so that caller can read it with a separate call
+		if !res[i].Success || len(res[i].ReturnData) == 0 {
+			continue
+		}
+		values, err := m.erc20ABI.Unpack(method, res[i].ReturnData)
+		if err != nil || len(values) == 0 {
+			continue
+		}
+
+		switch method {
+		case "balanceOf":
+			td.Balance, _ = values[0].(*big.Int)
+		case "totalSupply":
+			td.TotalSupply, _ = values[0].(*big.Int)
+		}
+	}
+
+	return td, nil
+}
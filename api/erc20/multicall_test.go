@@ -0,0 +1,211 @@
+package erc20
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+const testERC20ABI = `[
+	{"name":"name","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+	{"name":"symbol","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+	{"name":"decimals","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint8"}]},
+	{"name":"totalSupply","type":"function","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+	{"name":"balanceOf","type":"function","stateMutability":"view","inputs":[{"name":"owner","type":"address"}],"outputs":[{"name":"","type":"uint256"}]}
+]`
+
+const testMulticallABI = `[{
+	"name":"aggregate3","type":"function","stateMutability":"payable",
+	"inputs":[{"name":"calls","type":"tuple[]","components":[
+		{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}]}],
+	"outputs":[{"name":"returnData","type":"tuple[]","components":[
+		{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}]}]
+}]`
+
+func mustABI(t *testing.T, s string) abi.ABI {
+	t.Helper()
+	a, err := abi.JSON(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func pack(t *testing.T, typ string, v interface{}) []byte {
+	t.Helper()
+	out, err := mustArguments(typ).Pack(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+// multicallCaller serves aggregate3 calls, answering every aggregated call with the result of its method
+type multicallCaller struct {
+	erc20ABI     abi.ABI
+	multicallABI abi.ABI
+
+	notDeployed bool
+	err         error
+	results     map[string]Result
+}
+
+func (mc *multicallCaller) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	if mc.notDeployed {
+		return nil, nil
+	}
+	return []byte{0x01}, nil
+}
+
+func (mc *multicallCaller) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if mc.err != nil {
+		return nil, mc.err
+	}
+	if mc.notDeployed {
+		return nil, nil
+	}
+
+	aggregate3 := mc.multicallABI.Methods["aggregate3"]
+	args, err := aggregate3.Inputs.Unpack(call.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	calls := *abi.ConvertType(args[0], new([]Call3)).(*[]Call3)
+
+	res := make([]Result, len(calls))
+	for i, c := range calls {
+		m, err := mc.erc20ABI.MethodById(c.CallData[:4])
+		if err != nil {
+			return nil, err
+		}
+		res[i] = mc.results[m.Name]
+	}
+	return aggregate3.Outputs.Pack(res)
+}
+
+func TestMulticallTokenData(t *testing.T) {
+	erc20ABI := mustABI(t, testERC20ABI)
+	multicallABI := mustABI(t, testMulticallABI)
+
+	revert := Result{Success: false, ReturnData: append([]byte{0x08, 0xc3, 0x79, 0xa0}, pack(t, "string", "not implemented")...)}
+	ok := map[string]Result{
+		"name":        {Success: true, ReturnData: pack(t, "string", "Token")},
+		"symbol":      {Success: true, ReturnData: pack(t, "string", "TKN")},
+		"decimals":    {Success: true, ReturnData: pack(t, "uint256", big.NewInt(18))},
+		"balanceOf":   {Success: true, ReturnData: pack(t, "uint256", big.NewInt(100))},
+		"totalSupply": {Success: true, ReturnData: pack(t, "uint256", big.NewInt(1000))},
+	}
+	with := func(method string, r Result) map[string]Result {
+		results := map[string]Result{}
+		for k, v := range ok {
+			results[k] = v
+		}
+		results[method] = r
+		return results
+	}
+
+	tests := []struct {
+		name        string
+		caller      *multicallCaller
+		details     structures.Details
+		balance     *big.Int
+		totalSupply *big.Int
+		err         error
+		available   bool
+	}{
+		{
+			name:        "all calls succeed",
+			caller:      &multicallCaller{results: ok},
+			details:     structures.Details{Name: "Token", Symbol: "TKN", Decimals: 18},
+			balance:     big.NewInt(100),
+			totalSupply: big.NewInt(1000),
+			available:   true,
+		}, {
+			name:        "name reverts",
+			caller:      &multicallCaller{results: with("name", revert)},
+			details:     structures.Details{Symbol: "TKN", Decimals: 18, Unavailable: []string{FieldName}},
+			balance:     big.NewInt(100),
+			totalSupply: big.NewInt(1000),
+			available:   true,
+		}, {
+			name:        "decimals out of range",
+			caller:      &multicallCaller{results: with("decimals", Result{Success: true, ReturnData: pack(t, "uint256", big.NewInt(300))})},
+			details:     structures.Details{Name: "Token", Symbol: "TKN", Unavailable: []string{FieldDecimals}},
+			balance:     big.NewInt(100),
+			totalSupply: big.NewInt(1000),
+			available:   true,
+		}, {
+			name:        "balance call fails",
+			caller:      &multicallCaller{results: with("balanceOf", revert)},
+			details:     structures.Details{Name: "Token", Symbol: "TKN", Decimals: 18},
+			totalSupply: big.NewInt(1000),
+			available:   true,
+		}, {
+			name:      "total supply returns garbage",
+			caller:    &multicallCaller{results: with("totalSupply", Result{Success: true, ReturnData: []byte{0x01}})},
+			details:   structures.Details{Name: "Token", Symbol: "TKN", Decimals: 18},
+			balance:   big.NewInt(100),
+			available: true,
+		}, {
+			name:   "multicall not deployed",
+			caller: &multicallCaller{notDeployed: true},
+			err:    ErrMulticallNotDeployed,
+		}, {
+			name:      "node error",
+			caller:    &multicallCaller{err: errors.New("connection refused")},
+			available: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.caller.erc20ABI = erc20ABI
+			tt.caller.multicallABI = multicallABI
+
+			m := NewMulticall(erc20ABI)
+			mc := bind.NewBoundContract(common.HexToAddress(Multicall3Address), multicallABI, tt.caller, nil, nil)
+			holder := common.HexToAddress("0x01")
+
+			td, err := m.TokenData(context.Background(), mc, common.HexToAddress("0x02"), &holder, true, structures.LatestBlock)
+			if m.Available(structures.LatestBlock) != tt.available {
+				t.Errorf("Available() = %v, want %v", !tt.available, tt.available)
+			}
+			if tt.caller.err != nil || tt.err != nil {
+				if err == nil || tt.err != nil && !errors.Is(err, tt.err) {
+					t.Fatalf("TokenData() error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("TokenData() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(td.Details, tt.details) {
+				t.Errorf("Details = %+v, want %+v", td.Details, tt.details)
+			}
+			if !equalBig(td.Balance, tt.balance) {
+				t.Errorf("Balance = %v, want %v", td.Balance, tt.balance)
+			}
+			if !equalBig(td.TotalSupply, tt.totalSupply) {
+				t.Errorf("TotalSupply = %v, want %v", td.TotalSupply, tt.totalSupply)
+			}
+		})
+	}
+}
+
+func equalBig(a, b *big.Int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Cmp(b) == 0
+}
@@ -0,0 +1,185 @@
+package erc721
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+	"github.com/figment-networks/ethereum-worker/api/erc165"
+	"github.com/figment-networks/ethereum-worker/api/erc20"
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+// ERC165 interface ids
+var (
+	InterfaceERC165         = [4]byte{0x01, 0xff, 0xc9, 0xa7}
+	InterfaceERC721         = [4]byte{0x80, 0xac, 0x58, 0xcd}
+	InterfaceERC721Metadata = [4]byte{0x5b, 0x5e, 0x13, 0x9f}
+)
+
+type ERC721Caller struct {
+	NodeType erc20.EthereumNodeType
+	// Timeout bounds every call including its retries, conn.DefaultCallTimeout when not set
+	Timeout time.Duration
+}
+
+func (c *ERC721Caller) callOpts(ctx context.Context, bs structures.BlockSelector) (*bind.CallOpts, error) {
+	if c.NodeType != erc20.ENTArchive {
+		bs = structures.LatestBlock
+	}
+	return conn.CallOpts(ctx, bs)
+}
+
+func (c *ERC721Caller) BalanceOf(ctx context.Context, bc *bind.BoundContract, owner common.Address, bs structures.BlockSelector) (balance big.Int, err error) {
+	ctxT, cancel := conn.WithCallTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	co, err := c.callOpts(ctxT, bs)
+	if err != nil {
+		return balance, err
+	}
+
+	results := []interface{}{}
+	err = bc.Call(co, &results, "balanceOf", owner)
+	if err != nil {
+		return balance, fmt.Errorf("error calling balanceOf function %w", err)
+	}
+
+	if len(results) == 0 {
+		return balance, errors.New("empty result")
+	}
+
+	b, ok := results[0].(*big.Int)
+	if !ok {
+		return balance, errors.New("balance is not *big.Int type")
+	}
+
+	return *b, nil
+}
+
+func (c *ERC721Caller) OwnerOf(ctx context.Context, bc *bind.BoundContract, tokenID *big.Int, bs structures.BlockSelector) (owner common.Address, err error) {
+	ctxT, cancel := conn.WithCallTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	co, err := c.callOpts(ctxT, bs)
+	if err != nil {
+		return owner, err
+	}
+
+	results := []interface{}{}
+	err = bc.Call(co, &results, "ownerOf", tokenID)
+	if err != nil {
+		return owner, fmt.Errorf("error calling ownerOf function %w", err)
+	}
+
+	if len(results) == 0 {
+		return owner, errors.New("empty result")
+	}
+
+	o, ok := results[0].(common.Address)
+	if !ok {
+		return owner, errors.New("owner is not common.Address type")
+	}
+
+	return o, nil
+}
+
+func (c *ERC721Caller) TokenURI(ctx context.Context, bc *bind.BoundContract, tokenID *big.Int, bs structures.BlockSelector) (uri string, err error) {
+	ctxT, cancel := conn.WithCallTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	co, err := c.callOpts(ctxT, bs)
+	if err != nil {
+		return uri, err
+	}
+
+	results := []interface{}{}
+	err = bc.Call(co, &results, "tokenURI", tokenID)
+	if err != nil {
+		return uri, fmt.Errorf("error calling tokenURI function %w", err)
+	}
+
+	if len(results) == 0 {
+		return uri, errors.New("empty result")
+	}
+
+	u, ok := results[0].(string)
+	if !ok {
+		return uri, errors.New("token uri is not a string type")
+	}
+
+	return u, nil
+}
+
+func (c *ERC721Caller) Name(ctx context.Context, bc *bind.BoundContract, bs structures.BlockSelector) (name string, err error) {
+	ctxT, cancel := conn.WithCallTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	co, err := c.callOpts(ctxT, bs)
+	if err != nil {
+		return name, err
+	}
+
+	results := []interface{}{}
+	err = bc.Call(co, &results, "name")
+	if err != nil {
+		return name, fmt.Errorf("error calling name function %w", err)
+	}
+
+	if len(results) == 0 {
+		return name, errors.New("empty result")
+	}
+
+	n, ok := results[0].(string)
+	if !ok {
+		return name, errors.New("name is not a string type")
+	}
+
+	return n, nil
+}
+
+func (c *ERC721Caller) Symbol(ctx context.Context, bc *bind.BoundContract, bs structures.BlockSelector) (symbol string, err error) {
+	ctxT, cancel := conn.WithCallTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	co, err := c.callOpts(ctxT, bs)
+	if err != nil {
+		return symbol, err
+	}
+
+	results := []interface{}{}
+	err = bc.Call(co, &results, "symbol")
+	if err != nil {
+		return symbol, fmt.Errorf("error calling symbol function %w", err)
+	}
+
+	if len(results) == 0 {
+		return symbol, errors.New("empty result")
+	}
+
+	s, ok := results[0].(string)
+	if !ok {
+		return symbol, errors.New("symbol is not a string type")
+	}
+
+	return s, nil
+}
+
+// SupportsInterface checks ERC165 interface support. Contracts not implementing ERC165 report false.
+func (c *ERC721Caller) SupportsInterface(ctx context.Context, bcc conn.BoundContractCaller, interfaceID [4]byte, bs structures.BlockSelector) (supported bool, err error) {
+	ctxT, cancel := conn.WithCallTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	co, err := c.callOpts(ctxT, bs)
+	if err != nil {
+		return false, err
+	}
+
+	return erc165.SupportsInterface(bcc, co, interfaceID)
+}
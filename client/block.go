@@ -0,0 +1,66 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+// ErrHeightNotAvailable is returned for blocks which state was pruned by the node
+var ErrHeightNotAvailable = errors.New("height not available on this node")
+
+// resolveBlock resolves block tags to the block number, so all the calls of one request
+// read the same state. It returns selector to call with and the block to report in response.
+// Pending state has no stable number, so it is reported on top of the latest block.
+// Blocks which state the node does not serve are rejected with ErrHeightNotAvailable.
+func (c *Client) resolveBlock(ctx context.Context, ch *Chain, bs structures.BlockSelector) (structures.BlockSelector, *structures.Block, error) {
+	if bs.Tag == structures.BlockNumber {
+		if h := ch.t.History(); !h.Available(bs.Number) {
+			return bs, nil, fmt.Errorf("%w: block %d, oldest available is %d", ErrHeightNotAvailable, bs.Number, h.Oldest)
+		}
+		return bs, &structures.Block{Height: bs.Number}, nil
+	}
+
+	// concurrent requests for the same chain and tag share the header lookup
+	tag := bs.String()
+	if bs.Tag == structures.BlockPending {
+		tag = structures.LatestBlock.String()
+	}
+	var h *types.Header
+	if tag == structures.LatestBlock.String() {
+		// subscribed transports know the head without asking the node
+		h = ch.t.Head()
+	}
+	if h == nil {
+		v, err := shareCall(ctx, &c.sf, fmt.Sprintf("block|%d|%s", ch.ID, tag), c.callTimeout, func(ctx context.Context) (interface{}, error) {
+			if tag == structures.LatestBlock.String() {
+				return ch.t.HeaderByNumber(ctx, nil)
+			}
+			return ch.t.HeaderByTag(ctx, tag)
+		})
+		if err != nil {
+			return bs, nil, fmt.Errorf("error resolving %s block: %w", bs, err)
+		}
+		h = v.(*types.Header)
+	}
+
+	t := time.Unix(int64(h.Time), 0).UTC()
+	blk := &structures.Block{Height: h.Number.Uint64(), Time: &t}
+	if bs.Tag == structures.BlockPending {
+		blk.Pending = true
+		return bs, blk, nil
+	}
+	return structures.NumberBlock(blk.Height), blk, nil
+}
+
+func setBlock(bals []structures.Balance, blk *structures.Block) []structures.Balance {
+	for i := range bals {
+		bals[i].Block = blk
+	}
+	return bals
+}
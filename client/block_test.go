@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+func TestResolveBlock(t *testing.T) {
+	finalized := uint64(5)
+
+	tests := []struct {
+		name      string
+		bs        structures.BlockSelector
+		history   conn.History
+		head      *types.Header
+		finalized *uint64
+		want      structures.BlockSelector
+		height    uint64
+		pending   bool
+		err       error
+		calls     map[string]int
+	}{
+		{
+			name:   "block number",
+			bs:     structures.NumberBlock(3),
+			want:   structures.NumberBlock(3),
+			height: 3,
+			calls:  map[string]int{"HeaderByNumber": 0},
+		}, {
+			name:    "pruned block number",
+			bs:      structures.NumberBlock(3),
+			history: conn.History{Oldest: 4, Probed: time.Now()},
+			err:     ErrHeightNotAvailable,
+		}, {
+			name:   "latest",
+			bs:     structures.LatestBlock,
+			want:   structures.NumberBlock(9),
+			height: 9,
+			calls:  map[string]int{"HeaderByNumber": 1},
+		}, {
+			name:   "latest from subscription",
+			bs:     structures.LatestBlock,
+			head:   &types.Header{Number: big.NewInt(10), Time: 100},
+			want:   structures.NumberBlock(10),
+			height: 10,
+			calls:  map[string]int{"HeaderByNumber": 0},
+		}, {
+			name:    "pending",
+			bs:      structures.BlockSelector{Tag: structures.BlockPending},
+			want:    structures.BlockSelector{Tag: structures.BlockPending},
+			height:  9,
+			pending: true,
+		}, {
+			name:      "finalized",
+			bs:        structures.BlockSelector{Tag: structures.BlockFinalized},
+			finalized: &finalized,
+			want:      structures.NumberBlock(5),
+			height:    5,
+			calls:     map[string]int{"HeaderByTag": 1},
+		}, {
+			name: "finalized not supported",
+			bs:   structures.BlockSelector{Tag: structures.BlockFinalized},
+			err:  errors.New("error resolving finalized block: invalid argument 0: hex string without 0x prefix"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ft := newTestClient(&fakeERC20{}, 10)
+			ft.history, ft.head, ft.finalized = tt.history, tt.head, tt.finalized
+
+			bs, blk, err := c.resolveBlock(context.Background(), c.Chains()[0], tt.bs)
+			if tt.err != nil {
+				if err == nil || !errors.Is(err, tt.err) && err.Error() != tt.err.Error() {
+					t.Fatalf("error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if bs != tt.want {
+				t.Errorf("selector = %v, want %v", bs, tt.want)
+			}
+			if blk == nil || blk.Height != tt.height || blk.Pending != tt.pending {
+				t.Errorf("block = %+v, want height %d pending %v", blk, tt.height, tt.pending)
+			}
+			for method, n := range tt.calls {
+				if got := ft.get(method); got != n {
+					t.Errorf("%s called %d times, want %d", method, got, n)
+				}
+			}
+		})
+	}
+}
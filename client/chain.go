@@ -0,0 +1,162 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+var (
+	ErrUnknownChain  = errors.New("unknown chain")
+	ErrChainMismatch = errors.New("network is registered on a different chain")
+)
+
+// Chain is a single chain client reads from, with its own transport and block state
+type Chain struct {
+	ID   uint64
+	Name string
+
+	t conn.EthereumTransport
+
+	mc    MulticallAPI
+	mcBCC conn.BoundContractCaller
+
+	nativeDetails structures.Details
+
+	btc *BlockTimeCache
+
+	finalizedL       sync.Mutex
+	finalized        uint64
+	finalizedChecked time.Time
+	// finalizedHead is the subscribed head finalized head was read at
+	finalizedHead uint64
+	finalizedSF   singleflight.Group
+}
+
+// AddChain registers chain reachable through the transport. The first chain added is the default one,
+// used by requests selecting neither chain nor network registered on other chain.
+func (c *Client) AddChain(id uint64, name string, t conn.EthereumTransport) *Chain {
+	c.chainsL.Lock()
+	defer c.chainsL.Unlock()
+
+	ch := &Chain{
+		ID:            id,
+		Name:          name,
+		t:             t,
+		nativeDetails: EtherDetails,
+		btc:           NewBlockTimeCache(),
+	}
+	c.chains = append(c.chains, ch)
+	return ch
+}
+
+// Chains returns registered chains, the default one first
+func (c *Client) Chains() []*Chain {
+	c.chainsL.RLock()
+	defer c.chainsL.RUnlock()
+	return append([]*Chain(nil), c.chains...)
+}
+
+// SetMulticall enables aggregating cold cache lookups into single multicall contract call
+func (ch *Chain) SetMulticall(mc MulticallAPI, address string, multicallABI abi.ABI) {
+	ch.mc = mc
+	ch.mcBCC = ch.t.GetBoundContractCaller(common.HexToAddress(address), multicallABI)
+}
+
+// SetNativeDetails sets the details reported for chain's native currency, Ether by default
+func (ch *Chain) SetNativeDetails(det structures.Details) {
+	ch.nativeDetails = det
+}
+
+// Transport returns the chain's transport
+func (ch *Chain) Transport() conn.EthereumTransport {
+	return ch.t
+}
+
+// Is checks if chain is selected by s, which is either chain id or case insensitive chain name
+func (ch *Chain) Is(s string) bool {
+	return s == strconv.FormatUint(ch.ID, 10) || (ch.Name != "" && strings.EqualFold(s, ch.Name))
+}
+
+func (ch *Chain) String() string {
+	if ch.Name != "" {
+		return ch.Name
+	}
+	return strconv.FormatUint(ch.ID, 10)
+}
+
+// ChainStatus returns served chains with their verified identities, the default chain first
+func (c *Client) ChainStatus() []structures.ChainStatus {
+	chains := c.Chains()
+	st := make([]structures.ChainStatus, len(chains))
+	for i, ch := range chains {
+		st[i] = structures.ChainStatus{ID: ch.ID, Name: ch.Name, Default: i == 0}
+		if id := ch.t.Identity(); !id.Verified.IsZero() {
+			st[i].ChainID = id.ChainID
+			st[i].Genesis = id.Genesis.Hex()
+			st[i].Verified = &id.Verified
+		}
+		if h := ch.t.Head(); h != nil {
+			t := time.Unix(int64(h.Time), 0).UTC()
+			st[i].Head = &structures.Block{Height: h.Number.Uint64(), Time: &t}
+		}
+	}
+	return st
+}
+
+// chain returns chain selected by id or name, or the default chain when selector is empty
+func (c *Client) chain(selector string) (*Chain, error) {
+	c.chainsL.RLock()
+	defer c.chainsL.RUnlock()
+
+	if len(c.chains) == 0 {
+		return nil, fmt.Errorf("%w: no chains configured", ErrUnknownChain)
+	}
+	if selector == "" {
+		return c.chains[0], nil
+	}
+	for _, ch := range c.chains {
+		if ch.Is(selector) {
+			return ch, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownChain, selector)
+}
+
+// chainByID returns registered chain with given id
+func (c *Client) chainByID(id uint64) (*Chain, error) {
+	return c.chain(strconv.FormatUint(id, 10))
+}
+
+// resolveChain selects the chain of the request. Registered network implies its chain,
+// so explicit selector has to match it. Otherwise selector is used, defaulting to the default chain.
+func (c *Client) resolveChain(selector, network string) (*Chain, error) {
+	if network != "" {
+		if cc, ok := c.ccm.GetByNetwork(network); ok {
+			ch, err := c.chainByID(cc.Chain)
+			if err != nil {
+				return nil, err
+			}
+			if selector != "" && !ch.Is(selector) {
+				return nil, fmt.Errorf("%w: %s is on %s", ErrChainMismatch, network, ch)
+			}
+			return ch, nil
+		}
+	}
+	return c.chain(selector)
+}
+
+// chainKey prefixes address with chain id, so the same address on different chains never collides
+func chainKey(chain uint64, address string) string {
+	return strconv.FormatUint(chain, 10) + "|" + strings.ToLower(address)
+}
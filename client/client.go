@@ -2,72 +2,168 @@ package client
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
+	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 
 	"github.com/figment-networks/ethereum-worker/api/conn"
+	"github.com/figment-networks/ethereum-worker/api/erc20"
+	"github.com/figment-networks/ethereum-worker/store"
 	"github.com/figment-networks/ethereum-worker/structures"
 	"github.com/figment-networks/indexing-engine/metrics"
 
 	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
 )
 
 type Erc20API interface {
-	TotalSupply(ctx context.Context, bc *bind.BoundContract, blockNumber uint64) (ts big.Int, err error)
-	BalanceOf(ctx context.Context, bc *bind.BoundContract, tokenHolder common.Address, blockNumber uint64) (balance big.Int, err error)
-	Name(ctx context.Context, bc *bind.BoundContract, blockNumber uint64) (name string, err error)
-	Symbol(ctx context.Context, bc *bind.BoundContract, blockNumber uint64) (symbol string, err error)
-	Decimals(ctx context.Context, bc *bind.BoundContract, blockNumber uint64) (res uint64, err error)
+	TotalSupply(ctx context.Context, bc *bind.BoundContract, bs structures.BlockSelector) (ts big.Int, err error)
+	BalanceOf(ctx context.Context, bc *bind.BoundContract, tokenHolder common.Address, bs structures.BlockSelector) (balance big.Int, err error)
+	Metadata(ctx context.Context, bcc conn.BoundContractCaller, bs structures.BlockSelector) (det structures.Details, err error)
+}
+
+type MulticallAPI interface {
+	Available(bs structures.BlockSelector) bool
+	TokenData(ctx context.Context, mc *bind.BoundContract, token common.Address, holder *common.Address, totalSupply bool, bs structures.BlockSelector) (td erc20.TokenData, err error)
 }
 
 var (
-	getAccountBalanceDuration *metrics.GroupObserver
+	getAccountBalanceDuration     *metrics.GroupObserver
+	getAccountBalancesDuration    *metrics.GroupObserver
 	getTotalNetworkSupplyDuration *metrics.GroupObserver
+	getTotalSuppliesDuration      *metrics.GroupObserver
+)
+
+var (
+	ErrEmptyAccountAddress = errors.New("account address must be set")
+	ErrEmptyContract       = errors.New("either network or contract address must be set")
 )
 
+const defaultBatchConcurrency = 10
+
 // Client connecting to indexer-manager
 type Client struct {
 	serverApi Erc20API
 	log       *zap.Logger
 	ccm       *ContractCacheManager
-	t         conn.EthereumTransport
 	erc20ABI  abi.ABI
+
+	chainsL sync.RWMutex
+	chains  []*Chain
+
+	batchConcurrency int
+	nativeNetworks   map[string]string
+	callTimeout      time.Duration
+
+	erc721API Erc721API
+	erc721ABI abi.ABI
+	erc721ccm *ContractCacheManager
+
+	erc1155API Erc1155API
+	erc1155ABI abi.ABI
+	erc1155ccm *ContractCacheManager
+
+	sf singleflight.Group
+
+	networksL    sync.Mutex
+	networks     map[string]structures.Network
+	networksFile string
+
+	rc    *ResultCache
+	store store.Store
 }
 
-// NewClient is a indexer-manager Client constructor
-func NewClient(log *zap.Logger, serverApi Erc20API, t conn.EthereumTransport, erc20ABI abi.ABI) *Client {
+// NewClient is a indexer-manager Client constructor, chains are registered with AddChain
+func NewClient(log *zap.Logger, serverApi Erc20API, erc20ABI abi.ABI) *Client {
 	return &Client{
 		log:       log,
-		t:         t,
 		serverApi: serverApi,
 		ccm:       NewContractCacheManager(),
+		networks:  make(map[string]structures.Network),
 		erc20ABI:  erc20ABI,
+
+		batchConcurrency: defaultBatchConcurrency,
+	}
+}
+
+// SetBatchConcurrency sets the number of batch items processed at the same time
+func (c *Client) SetBatchConcurrency(n int) {
+	if n > 0 {
+		c.batchConcurrency = n
 	}
 }
 
+// IsNodeUnavailable reports if err means ethereum nodes can not serve the request right now,
+// they failed with transient errors or their circuit breakers are open, so it may succeed later
+func IsNodeUnavailable(err error) bool {
+	return conn.IsUnavailable(err)
+}
+
+// IsQuotaExhausted reports if err means the request was not sent to ethereum nodes, as it would exceed their rate limits
+func IsQuotaExhausted(err error) bool {
+	return errors.Is(err, conn.ErrQuotaExhausted)
+}
+
+// SetCallTimeout sets the timeout of node calls made by the client itself, conn.DefaultCallTimeout when not set
+func (c *Client) SetCallTimeout(d time.Duration) {
+	c.callTimeout = d
+}
+
 func Init() {
 	getAccountBalanceDuration = endpointDuration.WithLabels("getAccountBalance")
+	getAccountBalancesDuration = endpointDuration.WithLabels("getAccountBalances")
+	getNativeAccountBalanceDuration = endpointDuration.WithLabels("getNativeAccountBalance")
+	getERC721AccountBalanceDuration = endpointDuration.WithLabels("getERC721AccountBalance")
+	getERC721OwnerDuration = endpointDuration.WithLabels("getERC721Owner")
+	getERC1155AccountBalancesDuration = endpointDuration.WithLabels("getERC1155AccountBalances")
+	getBlockAtTimestampDuration = endpointDuration.WithLabels("getBlockAtTimestamp")
 	getTotalNetworkSupplyDuration = endpointDuration.WithLabels("getTotalNetworkSupply")
+	getTotalSuppliesDuration = endpointDuration.WithLabels("getTotalSupplies")
 }
 
+// LoadNetworkNames registers network name for ERC20 contract on the default chain
 func (c *Client) LoadNetworkNames(ctx context.Context, name, address string) (err error) {
-	cc := &ContractCache{BCC: c.t.GetBoundContractCaller(common.HexToAddress(address), c.erc20ABI)}
-	if cc.Details, err = c.getERC20Details(ctx, cc.BCC.GetContract(), 0); err != nil {
-		return fmt.Errorf("error calling getERC20Details: %w", err)
-	}
-	c.ccm.Set(address, name, cc)
-	return nil
+	return c.LoadNetwork(ctx, structures.Network{Name: name, Address: address})
 }
 
 // GetAccountBalance returns account balance
-func (c *Client) GetERC20AccountBalance(ctx context.Context, network, contract, address string, height uint64) ([]structures.Balance, error) {
+func (c *Client) GetERC20AccountBalance(ctx context.Context, chain, network, contract, address string, bs structures.BlockSelector) ([]structures.Balance, error) {
 	timer := metrics.NewTimer(getAccountBalanceDuration)
 	defer timer.ObserveDuration()
 
+	ch, err := c.resolveChain(chain, network)
+	if err != nil {
+		return nil, err
+	}
+
+	bs, blk, err := c.resolveBlock(ctx, ch, bs)
+	if err != nil {
+		return nil, err
+	}
+
+	key, details := c.erc20ResultKey(ResultERC20Balance, ch, network, contract, address, bs.Number)
+	if b, ok := c.getResult(key, bs); ok {
+		return setDetails(b, details), nil
+	}
+
+	b, err := c.coalesce(ctx, key, bs, func(ctx context.Context) ([]structures.Balance, error) {
+		return c.getERC20AccountBalance(ctx, ch, network, contract, address, bs, blk)
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.setResult(ctx, ch, key, bs, b)
+	return b, nil
+}
+
+// getERC20AccountBalance reads ERC20 balance at resolved block
+func (c *Client) getERC20AccountBalance(ctx context.Context, ch *Chain, network, contract, address string, bs structures.BlockSelector, blk *structures.Block) ([]structures.Balance, error) {
 	var (
 		cc    *ContractCache
 		found bool
@@ -77,38 +173,151 @@ func (c *Client) GetERC20AccountBalance(ctx context.Context, network, contract,
 		cc, found = c.ccm.GetByNetwork(network)
 	}
 	if !found && address != "" {
-		cc, found = c.ccm.GetByAddress(contract)
+		cc, found = c.ccm.GetByAddress(ch.ID, contract)
+	}
+	if !found && contract != "" {
+		cc, found = c.storedContract(ch, contract)
 	}
 	if !found {
-		cc = &ContractCache{BCC: c.t.GetBoundContractCaller(common.HexToAddress(contract), c.erc20ABI)}
+		cc = &ContractCache{Address: contract, Chain: ch.ID, BCC: ch.t.GetBoundContractCaller(common.HexToAddress(contract), c.erc20ABI)}
+	}
+	if found && bs.Tag == structures.BlockNumber && bs.Number < cc.StartBlock {
+		// contract was not deployed yet
+		return []structures.Balance{{
+			Values:  structures.Values{Type: structures.TypeERC20},
+			Details: cc.Details,
+			Block:   blk,
+		}}, nil
+	}
+
+	// details read from the node are cached only once the value is read too
+	fetched := !found
+	if !found {
+		holder := common.HexToAddress(address)
+		td, ok, err := c.multicallTokenData(ctx, ch, contract, &holder, false, bs)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			cc.Details = td.Details
+			if td.Balance != nil {
+				c.cacheContract(ch.ID, contract, cc)
+				return []structures.Balance{{
+					Values: structures.Values{
+						Value: *td.Balance,
+						Type:  structures.TypeERC20,
+					},
+					Details: cc.Details,
+					Block:   blk,
+				}}, nil
+			}
+			// details are known now, balance is read with a separate call
+			found = true
+		}
 	}
 
 	contractC := cc.BCC.GetContract()
-	balance, err := c.serverApi.BalanceOf(ctx, contractC, common.HexToAddress(address), height)
+	balance, err := c.serverApi.BalanceOf(ctx, contractC, common.HexToAddress(address), bs)
 	if err != nil {
 		return nil, fmt.Errorf("error calling Balanceof: %w", err)
 	}
 
 	if !found {
-		if cc.Details, err = c.getERC20Details(ctx, contractC, height); err != nil {
+		if cc.Details, err = c.coalescedDetails(ctx, ch.ID, contract, cc.BCC, bs); err != nil {
 			return nil, fmt.Errorf("error calling getERC20Details: %w", err)
 		}
-		c.ccm.Set(contract, "", cc)
+	}
+	if fetched {
+		c.cacheContract(ch.ID, contract, cc)
 	}
 
 	return []structures.Balance{{
 		Values: structures.Values{
 			Value: balance,
+			Type:  structures.TypeERC20,
 		},
 		Details: cc.Details,
+		Block:   blk,
 	}}, nil
 }
 
+// GetAccountBalances returns balances for a batch of requests. Items are processed with bounded concurrency,
+// failure of one item is reported in its result and does not affect the others.
+func (c *Client) GetAccountBalances(ctx context.Context, reqs []structures.BalanceRequest) []structures.BalanceResult {
+	timer := metrics.NewTimer(getAccountBalancesDuration)
+	defer timer.ObserveDuration()
+
+	results := make([]structures.BalanceResult, len(reqs))
+	sem := make(chan struct{}, c.batchConcurrency)
+	wg := &sync.WaitGroup{}
+
+	for i, r := range reqs {
+		if r.AccountAddress == "" {
+			results[i].Error = ErrEmptyAccountAddress.Error()
+			continue
+		}
+		if r.Network == "" && r.ContractAddress == "" {
+			results[i].Error = ErrEmptyContract.Error()
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			results[i].Error = ctx.Err().Error()
+			continue
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int, r structures.BalanceRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			b, err := c.GetAccountBalance(ctx, r.Chain, r.Network, r.ContractAddress, r.AccountAddress, r.Height)
+			if err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Balances = b
+		}(i, r)
+	}
+	wg.Wait()
+
+	return results
+}
+
 // GetERC20TotalSupply returns the total supply of tokens for a contractAccount or network if we've assigned it in config
-func (c *Client) GetERC20TotalSupply(ctx context.Context, network, contract string, height uint64) ([]structures.Balance, error) {
+func (c *Client) GetERC20TotalSupply(ctx context.Context, chain, network, contract string, bs structures.BlockSelector) ([]structures.Balance, error) {
 	timer := metrics.NewTimer(getTotalNetworkSupplyDuration)
 	defer timer.ObserveDuration()
 
+	ch, err := c.resolveChain(chain, network)
+	if err != nil {
+		return nil, err
+	}
+
+	bs, blk, err := c.resolveBlock(ctx, ch, bs)
+	if err != nil {
+		return nil, err
+	}
+
+	key, details := c.erc20ResultKey(ResultERC20TotalSupply, ch, network, contract, "", bs.Number)
+	if b, ok := c.getResult(key, bs); ok {
+		return setDetails(b, details), nil
+	}
+
+	b, err := c.coalesce(ctx, key, bs, func(ctx context.Context) ([]structures.Balance, error) {
+		return c.getERC20TotalSupply(ctx, ch, network, contract, bs, blk)
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.setResult(ctx, ch, key, bs, b)
+	return b, nil
+}
+
+// getERC20TotalSupply reads ERC20 total supply at resolved block
+func (c *Client) getERC20TotalSupply(ctx context.Context, ch *Chain, network, contract string, bs structures.BlockSelector, blk *structures.Block) ([]structures.Balance, error) {
 	var (
 		cc    *ContractCache
 		found bool
@@ -117,43 +326,148 @@ func (c *Client) GetERC20TotalSupply(ctx context.Context, network, contract stri
 	if network != "" {
 		cc, found = c.ccm.GetByNetwork(network)
 	}
+	if !found && contract != "" {
+		cc, found = c.storedContract(ch, contract)
+	}
 	if !found {
-		cc = &ContractCache{BCC: c.t.GetBoundContractCaller(common.HexToAddress(contract), c.erc20ABI)}
+		cc = &ContractCache{Address: contract, Chain: ch.ID, BCC: ch.t.GetBoundContractCaller(common.HexToAddress(contract), c.erc20ABI)}
+	}
+	if found && bs.Tag == structures.BlockNumber && bs.Number < cc.StartBlock {
+		// contract was not deployed yet
+		return []structures.Balance{{
+			Values:  structures.Values{Type: structures.TypeERC20},
+			Details: cc.Details,
+			Block:   blk,
+		}}, nil
+	}
+
+	// details read from the node are cached only once the value is read too
+	fetched := !found
+	if !found {
+		td, ok, err := c.multicallTokenData(ctx, ch, contract, nil, true, bs)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			cc.Details = td.Details
+			if td.TotalSupply != nil {
+				c.cacheContract(ch.ID, contract, cc)
+				return []structures.Balance{{
+					Values: structures.Values{
+						Value: *td.TotalSupply,
+						Type:  structures.TypeERC20,
+					},
+					Details: cc.Details,
+					Block:   blk,
+				}}, nil
+			}
+			// details are known now, total supply is read with a separate call
+			found = true
+		}
 	}
 
 	contractC := cc.BCC.GetContract()
-	totalSupply, err := c.serverApi.TotalSupply(ctx, contractC, height)
+	totalSupply, err := c.serverApi.TotalSupply(ctx, contractC, bs)
 	if err != nil {
 		return nil, fmt.Errorf("error calling Balanceof: %w", err)
 	}
 
 	if !found {
-		if cc.Details, err = c.getERC20Details(ctx, contractC, height); err != nil {
+		if cc.Details, err = c.coalescedDetails(ctx, ch.ID, contract, cc.BCC, bs); err != nil {
 			return nil, fmt.Errorf("error calling getERC20Details: %w", err)
 		}
-		c.ccm.Set(contract, "", cc)
+	}
+	if fetched {
+		c.cacheContract(ch.ID, contract, cc)
 	}
 
 	return []structures.Balance{{
 		Values: structures.Values{
 			Value: totalSupply,
+			Type:  structures.TypeERC20,
 		},
 		Details: cc.Details,
+		Block:   blk,
 	}}, nil
 }
 
-func (c *Client) getERC20Details(ctx context.Context, bc *bind.BoundContract, blockNumber uint64) (det structures.Details, err error) {
-	details := structures.Details{}
-	if details.Name, err = c.serverApi.Name(ctx, bc, blockNumber); err != nil {
-		return details, fmt.Errorf("error calling Name: %w", err)
+// GetERC20TotalSupplies returns total supplies for a batch of requests, the same way GetAccountBalances does
+func (c *Client) GetERC20TotalSupplies(ctx context.Context, reqs []structures.TotalSupplyRequest) []structures.BalanceResult {
+	timer := metrics.NewTimer(getTotalSuppliesDuration)
+	defer timer.ObserveDuration()
+
+	results := make([]structures.BalanceResult, len(reqs))
+	sem := make(chan struct{}, c.batchConcurrency)
+	wg := &sync.WaitGroup{}
+
+	for i, r := range reqs {
+		if r.Network == "" && r.ContractAddress == "" {
+			results[i].Error = ErrEmptyContract.Error()
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			results[i].Error = ctx.Err().Error()
+			continue
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int, r structures.TotalSupplyRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			b, err := c.GetERC20TotalSupply(ctx, r.Chain, r.Network, r.ContractAddress, r.Height)
+			if err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Balances = b
+		}(i, r)
 	}
+	wg.Wait()
+
+	return results
+}
 
-	if details.Symbol, err = c.serverApi.Symbol(ctx, bc, blockNumber); err != nil {
-		return details, fmt.Errorf("error calling Symbol: %w", err)
+// multicallTokenData fetches token data in a single multicall. It returns false when multicall is not enabled,
+// not deployed at given height or its result could not be decoded, so caller should fall back to separate calls.
+// Other errors, e.g. of unavailable or rate limited node, are returned rather than doubling the load with the fallback.
+func (c *Client) multicallTokenData(ctx context.Context, ch *Chain, contract string, holder *common.Address, totalSupply bool, bs structures.BlockSelector) (td erc20.TokenData, ok bool, err error) {
+	if ch.mc == nil || !ch.mc.Available(bs) {
+		return td, false, nil
 	}
 
-	if details.Decimals, err = c.serverApi.Decimals(ctx, bc, blockNumber); err != nil {
-		return details, fmt.Errorf("error calling Decimals: %w", err)
+	td, err = ch.mc.TokenData(ctx, ch.mcBCC.GetContract(), common.HexToAddress(contract), holder, totalSupply, bs)
+	switch {
+	case err == nil:
+		return td, true, nil
+	case errors.Is(err, erc20.ErrMulticallNotDeployed):
+		c.log.Debug("Multicall not deployed, falling back to separate calls", zap.Stringer("chain", ch), zap.Stringer("block", bs))
+		return td, false, nil
+	case errors.Is(err, erc20.ErrMulticallDecode):
+		c.log.Debug("Multicall result not decoded, falling back to separate calls", zap.Stringer("chain", ch), zap.Stringer("block", bs), zap.Error(err))
+		return td, false, nil
+	}
+	return td, false, fmt.Errorf("error calling multicall: %w", err)
+}
+
+// cacheContract caches details read from the node, unless the token provided none of them,
+// as it happens when it has no code at the height they were read at
+func (c *Client) cacheContract(chain uint64, contract string, cc *ContractCache) {
+	if cc.Details.Unknown() {
+		return
+	}
+	c.setContract(chain, contract, "", cc)
+}
+
+func (c *Client) getERC20Details(ctx context.Context, bcc conn.BoundContractCaller, bs structures.BlockSelector) (det structures.Details, err error) {
+	if det, err = c.serverApi.Metadata(ctx, bcc, bs); err != nil {
+		return det, fmt.Errorf("error calling Metadata: %w", err)
+	}
+	if len(det.Unavailable) > 0 {
+		c.log.Debug("Token metadata partially unavailable", zap.Strings("fields", det.Unavailable))
 	}
-	return details, err
+	return det, nil
 }
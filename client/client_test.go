@@ -0,0 +1,448 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+	"github.com/figment-networks/ethereum-worker/api/erc20"
+	"github.com/figment-networks/ethereum-worker/store"
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+func TestMain(m *testing.M) {
+	Init()
+	os.Exit(m.Run())
+}
+
+// calls counts calls of fake methods
+type calls struct {
+	l sync.Mutex
+	n map[string]int
+}
+
+func (c *calls) inc(method string) {
+	c.l.Lock()
+	defer c.l.Unlock()
+	if c.n == nil {
+		c.n = map[string]int{}
+	}
+	c.n[method]++
+}
+
+func (c *calls) get(method string) int {
+	c.l.Lock()
+	defer c.l.Unlock()
+	return c.n[method]
+}
+
+// fakeTransport serves headers of a chain which block times are given by height
+type fakeTransport struct {
+	calls
+
+	times     []uint64
+	finalized *uint64
+	head      *types.Header
+	history   conn.History
+	err       error
+	// tagErr is returned for finalized tag when finalized is not set, by default the tag is unsupported
+	tagErr error
+}
+
+// unsupportedTagError is the error of nodes not knowing a block tag
+type unsupportedTagError struct{}
+
+func (unsupportedTagError) Error() string  { return "invalid argument 0: hex string without 0x prefix" }
+func (unsupportedTagError) ErrorCode() int { return -32602 }
+
+func (ft *fakeTransport) header(height uint64) *types.Header {
+	return &types.Header{Number: new(big.Int).SetUint64(height), Time: ft.times[height]}
+}
+
+func (ft *fakeTransport) Dial(ctx context.Context) error { return nil }
+func (ft *fakeTransport) Close(ctx context.Context)      {}
+func (ft *fakeTransport) GetBoundContractCaller(address common.Address, a abi.ABI) conn.BoundContractCaller {
+	return fakeBCC{}
+}
+func (ft *fakeTransport) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	ft.inc("BalanceAt")
+	return big.NewInt(1), ft.err
+}
+func (ft *fakeTransport) PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error) {
+	ft.inc("PendingBalanceAt")
+	return big.NewInt(1), ft.err
+}
+func (ft *fakeTransport) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	ft.inc("HeaderByNumber")
+	if ft.err != nil {
+		return nil, ft.err
+	}
+	if number == nil {
+		return ft.header(uint64(len(ft.times) - 1)), nil
+	}
+	if !number.IsUint64() || number.Uint64() >= uint64(len(ft.times)) {
+		return nil, ethereum.NotFound
+	}
+	return ft.header(number.Uint64()), nil
+}
+func (ft *fakeTransport) HeaderByTag(ctx context.Context, tag string) (*types.Header, error) {
+	ft.inc("HeaderByTag")
+	if ft.err != nil {
+		return nil, ft.err
+	}
+	if tag == "finalized" && ft.finalized != nil {
+		return ft.header(*ft.finalized), nil
+	}
+	if ft.tagErr != nil {
+		return nil, ft.tagErr
+	}
+	return nil, unsupportedTagError{}
+}
+func (ft *fakeTransport) History() conn.History   { return ft.history }
+func (ft *fakeTransport) Identity() conn.Identity { return conn.Identity{} }
+func (ft *fakeTransport) Head() *types.Header     { return ft.head }
+func (ft *fakeTransport) SyncProgress(ctx context.Context) (*ethereum.SyncProgress, error) {
+	return nil, nil
+}
+func (ft *fakeTransport) PeerCount(ctx context.Context) (uint64, error) { return 1, nil }
+func (ft *fakeTransport) NodeConns() []conn.NodeConn                    { return nil }
+
+type fakeBCC struct{}
+
+func (fakeBCC) GetContract() *bind.BoundContract { return nil }
+func (fakeBCC) CallRaw(opts *bind.CallOpts, input []byte) ([]byte, error) {
+	return nil, errors.New("not implemented")
+}
+
+// fakeERC20 returns the same values for every contract. When wait is set, BalanceOf blocks until it is closed.
+type fakeERC20 struct {
+	calls
+
+	balance     int64
+	totalSupply int64
+	details     structures.Details
+	err         error
+	wait        chan struct{}
+
+	inflightL   sync.Mutex
+	inflight    int
+	maxInflight int
+}
+
+func (fe *fakeERC20) TotalSupply(ctx context.Context, bc *bind.BoundContract, bs structures.BlockSelector) (big.Int, error) {
+	fe.inc("TotalSupply")
+	return *big.NewInt(fe.totalSupply), fe.err
+}
+
+func (fe *fakeERC20) BalanceOf(ctx context.Context, bc *bind.BoundContract, tokenHolder common.Address, bs structures.BlockSelector) (big.Int, error) {
+	fe.inc("BalanceOf")
+
+	fe.inflightL.Lock()
+	if fe.inflight++; fe.inflight > fe.maxInflight {
+		fe.maxInflight = fe.inflight
+	}
+	fe.inflightL.Unlock()
+	defer func() {
+		fe.inflightL.Lock()
+		fe.inflight--
+		fe.inflightL.Unlock()
+	}()
+
+	if fe.wait != nil {
+		select {
+		case <-fe.wait:
+		case <-ctx.Done():
+			return big.Int{}, ctx.Err()
+		}
+	}
+	return *big.NewInt(fe.balance), fe.err
+}
+
+func (fe *fakeERC20) Metadata(ctx context.Context, bcc conn.BoundContractCaller, bs structures.BlockSelector) (structures.Details, error) {
+	fe.inc("Metadata")
+	return fe.details, fe.err
+}
+
+type fakeMulticall struct {
+	calls
+
+	td  erc20.TokenData
+	err error
+}
+
+func (fm *fakeMulticall) Available(bs structures.BlockSelector) bool { return true }
+func (fm *fakeMulticall) TokenData(ctx context.Context, mc *bind.BoundContract, token common.Address, holder *common.Address, totalSupply bool, bs structures.BlockSelector) (erc20.TokenData, error) {
+	fm.inc("TokenData")
+	return fm.td, fm.err
+}
+
+// memStore is an in-memory store.Store
+type memStore struct {
+	data map[string][]byte
+}
+
+func newMemStore() *memStore { return &memStore{data: map[string][]byte{}} }
+
+func (ms *memStore) Get(bucket, key []byte) ([]byte, error) {
+	v, ok := ms.data[string(bucket)+"|"+string(key)]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return v, nil
+}
+
+func (ms *memStore) Put(bucket, key, value []byte) error {
+	ms.data[string(bucket)+"|"+string(key)] = value
+	return nil
+}
+
+func (ms *memStore) Close() error { return nil }
+
+// newTestClient returns client of a single chain with id 1 and block times 0, 10, 20...
+func newTestClient(api Erc20API, blocks int) (*Client, *fakeTransport) {
+	ft := &fakeTransport{times: make([]uint64, blocks)}
+	for i := range ft.times {
+		ft.times[i] = uint64(i) * 10
+	}
+	c := NewClient(zap.NewNop(), api, abi.ABI{})
+	c.AddChain(1, "mainnet", ft)
+	return c, ft
+}
+
+func TestMulticallFallback(t *testing.T) {
+	const contract = "0x00000000000000000000000000000000000000aa"
+	const account = "0x00000000000000000000000000000000000000bb"
+	apiDetails := structures.Details{Name: "Node", Symbol: "N", Decimals: 6}
+	mcDetails := structures.Details{Name: "Multicall", Symbol: "M", Decimals: 18}
+
+	tests := []struct {
+		name        string
+		totalSupply bool
+		mc          *fakeMulticall
+		value       int64
+		details     structures.Details
+		err         bool
+		apiCalls    map[string]int
+	}{
+		{
+			name:     "multicall succeeds",
+			mc:       &fakeMulticall{td: erc20.TokenData{Details: mcDetails, Balance: big.NewInt(7)}},
+			value:    7,
+			details:  mcDetails,
+			apiCalls: map[string]int{"BalanceOf": 0, "Metadata": 0},
+		}, {
+			name:     "multicall balance call failed",
+			mc:       &fakeMulticall{td: erc20.TokenData{Details: mcDetails}},
+			value:    5,
+			details:  mcDetails,
+			apiCalls: map[string]int{"BalanceOf": 1, "Metadata": 0},
+		}, {
+			name:        "multicall total supply call failed",
+			totalSupply: true,
+			mc:          &fakeMulticall{td: erc20.TokenData{Details: mcDetails}},
+			value:       50,
+			details:     mcDetails,
+			apiCalls:    map[string]int{"TotalSupply": 1, "Metadata": 0},
+		}, {
+			name:     "multicall result not decoded",
+			mc:       &fakeMulticall{err: fmt.Errorf("%w: empty result", erc20.ErrMulticallDecode)},
+			value:    5,
+			details:  apiDetails,
+			apiCalls: map[string]int{"BalanceOf": 1, "Metadata": 1},
+		}, {
+			name:     "node unavailable",
+			mc:       &fakeMulticall{err: rpc.HTTPError{StatusCode: http.StatusServiceUnavailable}},
+			err:      true,
+			apiCalls: map[string]int{"BalanceOf": 0, "Metadata": 0},
+		}, {
+			name:        "multicall not deployed",
+			totalSupply: true,
+			mc:          &fakeMulticall{err: erc20.ErrMulticallNotDeployed},
+			value:       50,
+			details:     apiDetails,
+			apiCalls:    map[string]int{"TotalSupply": 1, "Metadata": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api := &fakeERC20{balance: 5, totalSupply: 50, details: apiDetails}
+			c, _ := newTestClient(api, 10)
+			c.Chains()[0].SetMulticall(tt.mc, erc20.Multicall3Address, abi.ABI{})
+
+			var (
+				b   []structures.Balance
+				err error
+			)
+			if tt.totalSupply {
+				b, err = c.GetERC20TotalSupply(context.Background(), "", "", contract, structures.LatestBlock)
+			} else {
+				b, err = c.GetERC20AccountBalance(context.Background(), "", "", contract, account, structures.LatestBlock)
+			}
+			for method, n := range tt.apiCalls {
+				if got := api.get(method); got != n {
+					t.Errorf("%s called %d times, want %d", method, got, n)
+				}
+			}
+			if tt.err {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(b) != 1 || b[0].Values.Value.Int64() != tt.value {
+				t.Errorf("balances = %+v, want value %d", b, tt.value)
+			}
+			if !reflect.DeepEqual(b[0].Details, tt.details) {
+				t.Errorf("details = %+v, want %+v", b[0].Details, tt.details)
+			}
+		})
+	}
+}
+
+func TestMulticallNoCode(t *testing.T) {
+	const contract = "0x00000000000000000000000000000000000000aa"
+	const account = "0x00000000000000000000000000000000000000bb"
+	noCode := structures.Details{Unavailable: []string{"name", "symbol", "decimals"}}
+
+	for _, totalSupply := range []bool{false, true} {
+		t.Run(fmt.Sprintf("total supply %v", totalSupply), func(t *testing.T) {
+			api := &fakeERC20{err: bind.ErrNoCode}
+			c, _ := newTestClient(api, 10)
+			mc := &fakeMulticall{td: erc20.TokenData{Details: noCode}}
+			c.Chains()[0].SetMulticall(mc, erc20.Multicall3Address, abi.ABI{})
+			st := newMemStore()
+			c.SetStore(st)
+
+			get := func() error {
+				var err error
+				if totalSupply {
+					_, err = c.GetERC20TotalSupply(context.Background(), "", "", contract, structures.LatestBlock)
+				} else {
+					_, err = c.GetERC20AccountBalance(context.Background(), "", "", contract, account, structures.LatestBlock)
+				}
+				return err
+			}
+
+			if err := get(); !errors.Is(err, bind.ErrNoCode) {
+				t.Fatalf("error = %v, want %v", err, bind.ErrNoCode)
+			}
+			if _, ok := c.ccm.GetByAddress(1, contract); ok {
+				t.Error("details of failed read are cached")
+			}
+			if len(st.data) != 0 {
+				t.Errorf("details of failed read are persisted: %v", st.data)
+			}
+
+			// token deployed later is read again, its unknown details are not cached either
+			api.err = nil
+			if err := get(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mc.get("TokenData") != 2 {
+				t.Errorf("multicall called %d times, want 2", mc.get("TokenData"))
+			}
+			if _, ok := c.ccm.GetByAddress(1, contract); ok {
+				t.Error("unknown details are cached")
+			}
+		})
+	}
+}
+
+func TestGetAccountBalances(t *testing.T) {
+	const contract = "0x00000000000000000000000000000000000000aa"
+
+	tests := []struct {
+		name   string
+		reqs   []structures.BalanceRequest
+		errors []string
+	}{
+		{
+			name: "all items succeed",
+			reqs: []structures.BalanceRequest{
+				{AccountAddress: "0x01", ContractAddress: contract},
+				{AccountAddress: "0x02", ContractAddress: contract, Height: structures.NumberBlock(3)},
+			},
+			errors: []string{"", ""},
+		}, {
+			name: "invalid items fail alone",
+			reqs: []structures.BalanceRequest{
+				{ContractAddress: contract},
+				{AccountAddress: "0x01"},
+				{AccountAddress: "0x02", ContractAddress: contract},
+				{AccountAddress: "0x03", ContractAddress: contract, Chain: "goerli"},
+			},
+			errors: []string{ErrEmptyAccountAddress.Error(), ErrEmptyContract.Error(), "", "unknown chain: goerli"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestClient(&fakeERC20{balance: 5}, 10)
+
+			results := c.GetAccountBalances(context.Background(), tt.reqs)
+			if len(results) != len(tt.reqs) {
+				t.Fatalf("got %d results, want %d", len(results), len(tt.reqs))
+			}
+			for i, r := range results {
+				if r.Error != tt.errors[i] {
+					t.Errorf("result %d error = %q, want %q", i, r.Error, tt.errors[i])
+				}
+				if r.Error == "" && (len(r.Balances) != 1 || r.Balances[0].Values.Value.Int64() != 5) {
+					t.Errorf("result %d balances = %+v, want value 5", i, r.Balances)
+				}
+			}
+		})
+	}
+}
+
+func TestGetAccountBalancesConcurrency(t *testing.T) {
+	api := &fakeERC20{balance: 5, wait: make(chan struct{})}
+	c, _ := newTestClient(api, 10)
+	c.SetBatchConcurrency(3)
+
+	reqs := make([]structures.BalanceRequest, 10)
+	for i := range reqs {
+		// different accounts, so that the lookups are not coalesced
+		reqs[i] = structures.BalanceRequest{AccountAddress: common.BigToAddress(big.NewInt(int64(i + 1))).Hex(), ContractAddress: "0xaa"}
+	}
+
+	done := make(chan []structures.BalanceResult)
+	go func() { done <- c.GetAccountBalances(context.Background(), reqs) }()
+	// let the items pile up at the limit before releasing them
+	for api.get("BalanceOf") < 3 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(api.wait)
+	results := <-done
+
+	for i, r := range results {
+		if r.Error != "" {
+			t.Errorf("result %d error = %s", i, r.Error)
+		}
+	}
+	if api.maxInflight > 3 {
+		t.Errorf("%d items processed at the same time, want at most 3", api.maxInflight)
+	}
+}
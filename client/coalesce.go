@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+// detachedContext keeps values of its parent but not its cancellation, so a call shared by several requests
+// is not aborted when the request which started it goes away
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (deadline time.Time, ok bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}                   { return nil }
+func (detachedContext) Err() error                              { return nil }
+func (d detachedContext) Value(key interface{}) interface{}     { return d.parent.Value(key) }
+
+// shareCall runs f once for all the callers of the key in flight. The shared call runs detached from callers'
+// contexts with its own timeout, every caller waits for it only until its own context is done.
+func shareCall(ctx context.Context, g *singleflight.Group, key string, timeout time.Duration, f func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	ch := g.DoChan(key, func() (interface{}, error) {
+		ctxT, cancel := conn.WithCallTimeout(detachedContext{parent: ctx}, timeout)
+		defer cancel()
+		return f(ctxT)
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-ch:
+		return r.Val, r.Err
+	}
+}
+
+// coalesce runs f once for all the identical requests in flight. Resolved block is part of the key,
+// so requests for different blocks never share the result. Every caller gets its own copy of the result.
+func (c *Client) coalesce(ctx context.Context, key ResultKey, bs structures.BlockSelector, f func(ctx context.Context) ([]structures.Balance, error)) ([]structures.Balance, error) {
+	v, err := shareCall(ctx, &c.sf, string(key.storeKey())+"|"+bs.String(), c.callTimeout, func(ctx context.Context) (interface{}, error) {
+		return f(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]structures.Balance(nil), v.([]structures.Balance)...), nil
+}
+
+// coalescedDetails fetches ERC20 details once for all the concurrent cold cache misses of the contract
+func (c *Client) coalescedDetails(ctx context.Context, chain uint64, contract string, bcc conn.BoundContractCaller, bs structures.BlockSelector) (structures.Details, error) {
+	v, err := shareCall(ctx, &c.sf, "details|"+chainKey(chain, contract), c.callTimeout, func(ctx context.Context) (interface{}, error) {
+		return c.getERC20Details(ctx, bcc, bs)
+	})
+	if err != nil {
+		return structures.Details{}, err
+	}
+	return v.(structures.Details), nil
+}
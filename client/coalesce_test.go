@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+func TestCoalesce(t *testing.T) {
+	key := newResultKey(ResultERC20Balance, 1, "", "0xaa", "0xbb", 10)
+
+	tests := []struct {
+		name string
+		// cancelFirst cancels the request which started the shared call before it finishes
+		cancelFirst bool
+		// other requests use different block
+		otherBlock bool
+		calls      int
+	}{
+		{name: "identical requests share call", calls: 1},
+		{name: "different blocks do not share call", otherBlock: true, calls: 3},
+		{name: "first request cancelled", cancelFirst: true, calls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestClient(&fakeERC20{}, 10)
+
+			var cl calls
+			started, release := make(chan struct{}), make(chan struct{})
+			f := func(ctx context.Context) ([]structures.Balance, error) {
+				cl.inc("f")
+				if cl.get("f") == 1 {
+					close(started)
+				}
+				select {
+				case <-release:
+				case <-ctx.Done():
+					return nil, ctx.Err()
+				}
+				return balances(1), nil
+			}
+
+			firstCtx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			firstErr := make(chan error, 1)
+			go func() {
+				_, err := c.coalesce(firstCtx, key, structures.NumberBlock(10), f)
+				firstErr <- err
+			}()
+			<-started
+
+			var wg sync.WaitGroup
+			for i := 0; i < 2; i++ {
+				bs := structures.NumberBlock(10)
+				if tt.otherBlock {
+					bs = structures.NumberBlock(uint64(11 + i))
+				}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					b, err := c.coalesce(context.Background(), key, bs, f)
+					if err != nil {
+						t.Errorf("unexpected error: %v", err)
+						return
+					}
+					if len(b) != 1 || b[0].Values.Value.Int64() != 1 {
+						t.Errorf("balances = %v", b)
+					}
+				}()
+			}
+
+			if tt.cancelFirst {
+				cancel()
+				if err := <-firstErr; !errors.Is(err, context.Canceled) {
+					t.Errorf("cancelled request error = %v, want %v", err, context.Canceled)
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			if !tt.cancelFirst {
+				if err := <-firstErr; err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+			if n := cl.get("f"); n != tt.calls {
+				t.Errorf("calls = %d, want %d", n, tt.calls)
+			}
+		})
+	}
+}
+
+func TestShareCallTimeout(t *testing.T) {
+	var sf singleflight.Group
+	_, err := shareCall(context.Background(), &sf, "k", 10*time.Millisecond, func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -9,8 +9,16 @@ import (
 )
 
 type ContractCache struct {
+	// Address is the contract address, set for registered networks
+	Address string
+	// Chain is the id of the chain contract is deployed on
+	Chain   uint64
 	BCC     conn.BoundContractCaller
 	Details structures.Details
+	// NoMetadata is set for NFT collections not implementing metadata extension
+	NoMetadata bool
+	// StartBlock is the block contract was deployed at
+	StartBlock uint64
 }
 
 type ContractCacheManager struct {
@@ -23,10 +31,11 @@ func NewContractCacheManager() *ContractCacheManager {
 	return &ContractCacheManager{addressMap: make(map[string]*ContractCache), networkMap: make(map[string]*ContractCache)}
 }
 
-func (cc *ContractCacheManager) GetByAddress(address string) (*ContractCache, bool) {
+// GetByAddress returns contract cached by its chain and address
+func (cc *ContractCacheManager) GetByAddress(chain uint64, address string) (*ContractCache, bool) {
 	cc.l.RLock()
 	defer cc.l.RUnlock()
-	b, ok := cc.addressMap[address]
+	b, ok := cc.addressMap[chainKey(chain, address)]
 	return b, ok
 }
 
@@ -37,12 +46,38 @@ func (cc *ContractCacheManager) GetByNetwork(network string) (*ContractCache, bo
 	return b, ok
 }
 
-func (cc *ContractCacheManager) Set(address, network string, contract *ContractCache) {
+// Set caches contract by its chain and address, network names are global across chains
+func (cc *ContractCacheManager) Set(chain uint64, address, network string, contract *ContractCache) {
 	cc.l.Lock()
 	defer cc.l.Unlock()
 
-	cc.addressMap[address] = contract
+	cc.addressMap[chainKey(chain, address)] = contract
 	if network != "" {
 		cc.networkMap[strings.ToLower(network)] = contract
 	}
 }
+
+// Networks returns registered networks by name
+func (cc *ContractCacheManager) Networks() map[string]*ContractCache {
+	cc.l.RLock()
+	defer cc.l.RUnlock()
+
+	nets := make(map[string]*ContractCache, len(cc.networkMap))
+	for name, c := range cc.networkMap {
+		nets[name] = c
+	}
+	return nets
+}
+
+// RemoveNetwork removes network name mapping, contract stays cached by its address
+func (cc *ContractCacheManager) RemoveNetwork(network string) bool {
+	cc.l.Lock()
+	defer cc.l.Unlock()
+
+	network = strings.ToLower(network)
+	if _, ok := cc.networkMap[network]; !ok {
+		return false
+	}
+	delete(cc.networkMap, network)
+	return true
+}
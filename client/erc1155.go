@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+	"github.com/figment-networks/ethereum-worker/api/erc1155"
+	"github.com/figment-networks/ethereum-worker/structures"
+	"github.com/figment-networks/indexing-engine/metrics"
+)
+
+type Erc1155API interface {
+	BalanceOfBatch(ctx context.Context, bc *bind.BoundContract, owners []common.Address, ids []*big.Int, bs structures.BlockSelector) (balances []*big.Int, err error)
+	URI(ctx context.Context, bc *bind.BoundContract, id *big.Int, bs structures.BlockSelector) (uri string, err error)
+	SupportsInterface(ctx context.Context, bcc conn.BoundContractCaller, interfaceID [4]byte, bs structures.BlockSelector) (supported bool, err error)
+}
+
+var (
+	ErrERC1155NotEnabled = errors.New("erc1155 support is not enabled")
+	ErrNotERC1155        = errors.New("contract does not implement ERC1155")
+)
+
+var getERC1155AccountBalancesDuration *metrics.GroupObserver
+
+// SetERC1155 enables ERC1155 lookups
+func (c *Client) SetERC1155(api Erc1155API, erc1155ABI abi.ABI) {
+	c.erc1155API = api
+	c.erc1155ABI = erc1155ABI
+	c.erc1155ccm = NewContractCacheManager()
+}
+
+// GetERC1155AccountBalances returns account balances of given token ids in a single balanceOfBatch call.
+// Token uris are fetched (one call per id) only when withURI is set.
+func (c *Client) GetERC1155AccountBalances(ctx context.Context, chain, contract, address string, ids []*big.Int, withURI bool, bs structures.BlockSelector) ([]structures.Balance, error) {
+	timer := metrics.NewTimer(getERC1155AccountBalancesDuration)
+	defer timer.ObserveDuration()
+
+	ch, err := c.chain(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	bs, blk, err := c.resolveBlock(ctx, ch, bs)
+	if err != nil {
+		return nil, err
+	}
+
+	cc, err := c.getERC1155Contract(ctx, ch, contract, bs)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := make([]common.Address, len(ids))
+	for i := range ids {
+		owners[i] = common.HexToAddress(address)
+	}
+
+	contractC := cc.BCC.GetContract()
+	balances, err := c.erc1155API.BalanceOfBatch(ctx, contractC, owners, ids, bs)
+	if err != nil {
+		return nil, fmt.Errorf("error calling BalanceOfBatch: %w", err)
+	}
+	// balances come from the contract, which does not have to conform to the standard
+	if len(balances) != len(ids) {
+		return nil, fmt.Errorf("error calling BalanceOfBatch: expected %d balances, got %d", len(ids), len(balances))
+	}
+
+	resp := make([]structures.Balance, 0, len(ids))
+	for i, id := range ids {
+		if balances[i] == nil {
+			continue
+		}
+		b := structures.Balance{
+			Values: structures.Values{
+				Value:   *balances[i],
+				Type:    structures.TypeERC1155,
+				TokenID: id,
+			},
+			Details: cc.Details,
+			Block:   blk,
+		}
+
+		if withURI {
+			if b.Values.URI, err = c.erc1155API.URI(ctx, contractC, id, bs); err != nil && !conn.IsContractError(err) {
+				return nil, fmt.Errorf("error calling URI: %w", err)
+			}
+		}
+		resp = append(resp, b)
+	}
+
+	return resp, nil
+}
+
+// getERC1155Contract returns cached contract, checking ERC165 interface on the first use
+func (c *Client) getERC1155Contract(ctx context.Context, ch *Chain, contract string, bs structures.BlockSelector) (*ContractCache, error) {
+	if c.erc1155API == nil {
+		return nil, ErrERC1155NotEnabled
+	}
+
+	if cc, ok := c.erc1155ccm.GetByAddress(ch.ID, contract); ok {
+		return cc, nil
+	}
+
+	cc := &ContractCache{Chain: ch.ID, BCC: ch.t.GetBoundContractCaller(common.HexToAddress(contract), c.erc1155ABI)}
+	supported, err := c.erc1155API.SupportsInterface(ctx, cc.BCC, erc1155.InterfaceERC1155, bs)
+	if err != nil {
+		return nil, fmt.Errorf("error calling SupportsInterface: %w", err)
+	}
+	if !supported {
+		return nil, ErrNotERC1155
+	}
+
+	// name and symbol are not part of ERC1155, but many contracts implement them
+	if cc.Details, err = c.getERC20Details(ctx, cc.BCC, bs); err != nil {
+		return nil, err
+	}
+
+	c.erc1155ccm.Set(ch.ID, contract, "", cc)
+	return cc, nil
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+type fakeERC1155 struct {
+	notSupported bool
+	balances     []*big.Int
+	uriErr       error
+}
+
+func (fe *fakeERC1155) BalanceOfBatch(ctx context.Context, bc *bind.BoundContract, owners []common.Address, ids []*big.Int, bs structures.BlockSelector) ([]*big.Int, error) {
+	return fe.balances, nil
+}
+
+func (fe *fakeERC1155) URI(ctx context.Context, bc *bind.BoundContract, id *big.Int, bs structures.BlockSelector) (string, error) {
+	if fe.uriErr != nil {
+		return "", fe.uriErr
+	}
+	return "ipfs://" + id.String(), nil
+}
+
+func (fe *fakeERC1155) SupportsInterface(ctx context.Context, bcc conn.BoundContractCaller, interfaceID [4]byte, bs structures.BlockSelector) (bool, error) {
+	return !fe.notSupported, nil
+}
+
+type contractError struct{}
+
+func (contractError) Error() string  { return "execution reverted" }
+func (contractError) ErrorCode() int { return 3 }
+
+func TestGetERC1155AccountBalances(t *testing.T) {
+	ids := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+
+	tests := []struct {
+		name   string
+		api    *fakeERC1155
+		values []int64
+		uris   []string
+		err    error
+	}{
+		{
+			name:   "balances with uris",
+			api:    &fakeERC1155{balances: []*big.Int{big.NewInt(10), big.NewInt(0), big.NewInt(30)}},
+			values: []int64{10, 0, 30},
+			uris:   []string{"ipfs://1", "ipfs://2", "ipfs://3"},
+		}, {
+			name:   "uri reverts",
+			api:    &fakeERC1155{balances: []*big.Int{big.NewInt(10), big.NewInt(0), big.NewInt(30)}, uriErr: contractError{}},
+			values: []int64{10, 0, 30},
+			uris:   []string{"", "", ""},
+		}, {
+			name:   "nil balance is skipped",
+			api:    &fakeERC1155{balances: []*big.Int{big.NewInt(10), nil, big.NewInt(30)}},
+			values: []int64{10, 30},
+			uris:   []string{"ipfs://1", "ipfs://3"},
+		}, {
+			name: "fewer balances than ids",
+			api:  &fakeERC1155{balances: []*big.Int{big.NewInt(10)}},
+			err:  errors.New("error calling BalanceOfBatch: expected 3 balances, got 1"),
+		}, {
+			name: "more balances than ids",
+			api:  &fakeERC1155{balances: []*big.Int{big.NewInt(10), big.NewInt(20), big.NewInt(30), big.NewInt(40)}},
+			err:  errors.New("error calling BalanceOfBatch: expected 3 balances, got 4"),
+		}, {
+			name: "not ERC1155",
+			api:  &fakeERC1155{notSupported: true},
+			err:  ErrNotERC1155,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestClient(&fakeERC20{}, 10)
+			c.SetERC1155(tt.api, abi.ABI{})
+
+			b, err := c.GetERC1155AccountBalances(context.Background(), "", "0xaa", "0xbb", ids, true, structures.LatestBlock)
+			if tt.err != nil {
+				if err == nil || err.Error() != tt.err.Error() {
+					t.Fatalf("error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(b) != len(tt.values) {
+				t.Fatalf("got %d balances, want %d", len(b), len(tt.values))
+			}
+			for i := range b {
+				if b[i].Values.Value.Int64() != tt.values[i] || b[i].Values.URI != tt.uris[i] || b[i].Values.Type != structures.TypeERC1155 {
+					t.Errorf("balance %d = %+v, want value %d and uri %q", i, b[i].Values, tt.values[i], tt.uris[i])
+				}
+			}
+		})
+	}
+}
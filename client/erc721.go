@@ -0,0 +1,167 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+	"github.com/figment-networks/ethereum-worker/api/erc20"
+	"github.com/figment-networks/ethereum-worker/api/erc721"
+	"github.com/figment-networks/ethereum-worker/structures"
+	"github.com/figment-networks/indexing-engine/metrics"
+)
+
+type Erc721API interface {
+	BalanceOf(ctx context.Context, bc *bind.BoundContract, owner common.Address, bs structures.BlockSelector) (balance big.Int, err error)
+	OwnerOf(ctx context.Context, bc *bind.BoundContract, tokenID *big.Int, bs structures.BlockSelector) (owner common.Address, err error)
+	TokenURI(ctx context.Context, bc *bind.BoundContract, tokenID *big.Int, bs structures.BlockSelector) (uri string, err error)
+	Name(ctx context.Context, bc *bind.BoundContract, bs structures.BlockSelector) (name string, err error)
+	Symbol(ctx context.Context, bc *bind.BoundContract, bs structures.BlockSelector) (symbol string, err error)
+	SupportsInterface(ctx context.Context, bcc conn.BoundContractCaller, interfaceID [4]byte, bs structures.BlockSelector) (supported bool, err error)
+}
+
+var (
+	ErrERC721NotEnabled = errors.New("erc721 support is not enabled")
+	ErrNotERC721        = errors.New("contract does not implement ERC721")
+	ErrTokenNotFound    = errors.New("token does not exist")
+)
+
+var (
+	getERC721AccountBalanceDuration *metrics.GroupObserver
+	getERC721OwnerDuration          *metrics.GroupObserver
+)
+
+// SetERC721 enables ERC721 lookups
+func (c *Client) SetERC721(api Erc721API, erc721ABI abi.ABI) {
+	c.erc721API = api
+	c.erc721ABI = erc721ABI
+	c.erc721ccm = NewContractCacheManager()
+}
+
+// GetERC721AccountBalance returns the number of NFTs account holds in the collection
+func (c *Client) GetERC721AccountBalance(ctx context.Context, chain, contract, address string, bs structures.BlockSelector) ([]structures.Balance, error) {
+	timer := metrics.NewTimer(getERC721AccountBalanceDuration)
+	defer timer.ObserveDuration()
+
+	ch, err := c.chain(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	bs, blk, err := c.resolveBlock(ctx, ch, bs)
+	if err != nil {
+		return nil, err
+	}
+
+	cc, err := c.getERC721Contract(ctx, ch, contract, bs)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := c.erc721API.BalanceOf(ctx, cc.BCC.GetContract(), common.HexToAddress(address), bs)
+	if err != nil {
+		return nil, fmt.Errorf("error calling BalanceOf: %w", err)
+	}
+
+	return []structures.Balance{{
+		Values: structures.Values{
+			Value: balance,
+			Type:  structures.TypeERC721,
+		},
+		Details: cc.Details,
+		Block:   blk,
+	}}, nil
+}
+
+// GetERC721Owner returns the owner of the token in the collection
+func (c *Client) GetERC721Owner(ctx context.Context, chain, contract string, tokenID *big.Int, bs structures.BlockSelector) (o structures.NFTOwner, err error) {
+	timer := metrics.NewTimer(getERC721OwnerDuration)
+	defer timer.ObserveDuration()
+
+	ch, err := c.chain(chain)
+	if err != nil {
+		return o, err
+	}
+
+	bs, blk, err := c.resolveBlock(ctx, ch, bs)
+	if err != nil {
+		return o, err
+	}
+
+	cc, err := c.getERC721Contract(ctx, ch, contract, bs)
+	if err != nil {
+		return o, err
+	}
+
+	contractC := cc.BCC.GetContract()
+	owner, err := c.erc721API.OwnerOf(ctx, contractC, tokenID, bs)
+	if err != nil {
+		if conn.IsContractError(err) {
+			return o, ErrTokenNotFound
+		}
+		return o, fmt.Errorf("error calling OwnerOf: %w", err)
+	}
+
+	o = structures.NFTOwner{
+		TokenID: *tokenID,
+		Owner:   owner.Hex(),
+		Details: cc.Details,
+		Block:   blk,
+	}
+
+	if !cc.NoMetadata {
+		if o.TokenURI, err = c.erc721API.TokenURI(ctx, contractC, tokenID, bs); err != nil {
+			if !conn.IsContractError(err) {
+				return o, fmt.Errorf("error calling TokenURI: %w", err)
+			}
+		}
+	}
+
+	return o, nil
+}
+
+// getERC721Contract returns cached collection, checking ERC165 interfaces on the first use
+func (c *Client) getERC721Contract(ctx context.Context, ch *Chain, contract string, bs structures.BlockSelector) (*ContractCache, error) {
+	if c.erc721API == nil {
+		return nil, ErrERC721NotEnabled
+	}
+
+	if cc, ok := c.erc721ccm.GetByAddress(ch.ID, contract); ok {
+		return cc, nil
+	}
+
+	cc := &ContractCache{Chain: ch.ID, BCC: ch.t.GetBoundContractCaller(common.HexToAddress(contract), c.erc721ABI)}
+	supported, err := c.erc721API.SupportsInterface(ctx, cc.BCC, erc721.InterfaceERC721, bs)
+	if err != nil {
+		return nil, fmt.Errorf("error calling SupportsInterface: %w", err)
+	}
+	if !supported {
+		return nil, ErrNotERC721
+	}
+
+	if supported, err = c.erc721API.SupportsInterface(ctx, cc.BCC, erc721.InterfaceERC721Metadata, bs); err != nil {
+		return nil, fmt.Errorf("error calling SupportsInterface: %w", err)
+	}
+
+	if !supported {
+		cc.NoMetadata = true
+		cc.Details.Unavailable = []string{erc20.FieldName, erc20.FieldSymbol}
+	} else {
+		contractC := cc.BCC.GetContract()
+		if cc.Details.Name, err = c.erc721API.Name(ctx, contractC, bs); err != nil {
+			cc.Details.Unavailable = append(cc.Details.Unavailable, erc20.FieldName)
+		}
+		if cc.Details.Symbol, err = c.erc721API.Symbol(ctx, contractC, bs); err != nil {
+			cc.Details.Unavailable = append(cc.Details.Unavailable, erc20.FieldSymbol)
+		}
+	}
+
+	c.erc721ccm.Set(ch.ID, contract, "", cc)
+	return cc, nil
+}
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+	"github.com/figment-networks/ethereum-worker/api/erc20"
+	"github.com/figment-networks/ethereum-worker/api/erc721"
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+type fakeERC721 struct {
+	calls
+
+	interfaces map[[4]byte]bool
+	ownerErr   error
+	nameErr    error
+}
+
+func (fe *fakeERC721) BalanceOf(ctx context.Context, bc *bind.BoundContract, owner common.Address, bs structures.BlockSelector) (big.Int, error) {
+	return *big.NewInt(2), nil
+}
+
+func (fe *fakeERC721) OwnerOf(ctx context.Context, bc *bind.BoundContract, tokenID *big.Int, bs structures.BlockSelector) (common.Address, error) {
+	return common.HexToAddress("0xbb"), fe.ownerErr
+}
+
+func (fe *fakeERC721) TokenURI(ctx context.Context, bc *bind.BoundContract, tokenID *big.Int, bs structures.BlockSelector) (string, error) {
+	fe.inc("TokenURI")
+	return "ipfs://" + tokenID.String(), nil
+}
+
+func (fe *fakeERC721) Name(ctx context.Context, bc *bind.BoundContract, bs structures.BlockSelector) (string, error) {
+	if fe.nameErr != nil {
+		return "", fe.nameErr
+	}
+	return "Kitties", nil
+}
+
+func (fe *fakeERC721) Symbol(ctx context.Context, bc *bind.BoundContract, bs structures.BlockSelector) (string, error) {
+	return "CK", nil
+}
+
+func (fe *fakeERC721) SupportsInterface(ctx context.Context, bcc conn.BoundContractCaller, interfaceID [4]byte, bs structures.BlockSelector) (bool, error) {
+	return fe.interfaces[interfaceID], nil
+}
+
+func TestGetERC721Owner(t *testing.T) {
+	all := map[[4]byte]bool{erc721.InterfaceERC721: true, erc721.InterfaceERC721Metadata: true}
+
+	tests := []struct {
+		name     string
+		api      *fakeERC721
+		want     structures.NFTOwner
+		tokenURI int
+		err      error
+	}{
+		{
+			name: "collection with metadata",
+			api:  &fakeERC721{interfaces: all},
+			want: structures.NFTOwner{
+				Owner:    common.HexToAddress("0xbb").Hex(),
+				TokenURI: "ipfs://7",
+				Details:  structures.Details{Name: "Kitties", Symbol: "CK"},
+			},
+			tokenURI: 1,
+		}, {
+			name: "collection without metadata",
+			api:  &fakeERC721{interfaces: map[[4]byte]bool{erc721.InterfaceERC721: true}},
+			want: structures.NFTOwner{
+				Owner:   common.HexToAddress("0xbb").Hex(),
+				Details: structures.Details{Unavailable: []string{erc20.FieldName, erc20.FieldSymbol}},
+			},
+		}, {
+			name: "name reverts",
+			api:  &fakeERC721{interfaces: all, nameErr: contractError{}},
+			want: structures.NFTOwner{
+				Owner:    common.HexToAddress("0xbb").Hex(),
+				TokenURI: "ipfs://7",
+				Details:  structures.Details{Symbol: "CK", Unavailable: []string{erc20.FieldName}},
+			},
+			tokenURI: 1,
+		}, {
+			name: "token does not exist",
+			api:  &fakeERC721{interfaces: all, ownerErr: contractError{}},
+			err:  ErrTokenNotFound,
+		}, {
+			name: "not ERC721",
+			api:  &fakeERC721{},
+			err:  ErrNotERC721,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := newTestClient(&fakeERC20{}, 10)
+			c.SetERC721(tt.api, abi.ABI{})
+
+			o, err := c.GetERC721Owner(context.Background(), "", "0xaa", big.NewInt(7), structures.NumberBlock(5))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			tt.want.TokenID = *big.NewInt(7)
+			tt.want.Block = &structures.Block{Height: 5}
+			if !reflect.DeepEqual(o, tt.want) {
+				t.Errorf("GetERC721Owner() = %+v, want %+v", o, tt.want)
+			}
+			if n := tt.api.get("TokenURI"); n != tt.tokenURI {
+				t.Errorf("TokenURI called %d times, want %d", n, tt.tokenURI)
+			}
+		})
+	}
+}
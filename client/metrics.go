@@ -10,4 +10,12 @@ var (
 		Desc:      "Duration how long it takes for each endpoint",
 		Tags:      []string{"type"},
 	})
+
+	resultCacheLookups = metrics.MustNewCounterWithTags(metrics.Options{
+		Namespace: "indexerworker",
+		Subsystem: "client",
+		Name:      "result_cache_lookups",
+		Desc:      "Number of result cache lookups by result",
+		Tags:      []string{"type", "result"},
+	})
 )
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+	"github.com/figment-networks/ethereum-worker/structures"
+	"github.com/figment-networks/indexing-engine/metrics"
+)
+
+// EtherDetails are the default details returned for native currency balances
+var EtherDetails = structures.Details{
+	Name:     "Ether",
+	Symbol:   "ETH",
+	Decimals: 18,
+}
+
+var getNativeAccountBalanceDuration *metrics.GroupObserver
+
+// SetNativeNetworks sets network names that resolve to the native currency of the selected chain instead of ERC20 contract
+func (c *Client) SetNativeNetworks(names []string) {
+	c.nativeNetworks = make(map[string]string, len(names))
+	for _, n := range names {
+		c.nativeNetworks[strings.ToLower(n)] = ""
+	}
+}
+
+// SetNativeNetwork sets network name that resolves to the native currency of given chain
+func (c *Client) SetNativeNetwork(name, chain string) {
+	if c.nativeNetworks == nil {
+		c.nativeNetworks = make(map[string]string)
+	}
+	c.nativeNetworks[strings.ToLower(name)] = chain
+}
+
+// IsNativeNetwork checks if network name resolves to native currency
+func (c *Client) IsNativeNetwork(network string) bool {
+	_, ok := c.nativeNetworks[strings.ToLower(network)]
+	return ok
+}
+
+// GetAccountBalance returns native balance for native networks and ERC20 balance otherwise.
+// Networks registered as ERC20 take precedence over native ones.
+func (c *Client) GetAccountBalance(ctx context.Context, chain, network, contract, address string, bs structures.BlockSelector) ([]structures.Balance, error) {
+	if contract == "" && c.IsNativeNetwork(network) {
+		if _, found := c.ccm.GetByNetwork(network); !found {
+			nativeChain, err := c.nativeChain(chain, network)
+			if err != nil {
+				return nil, err
+			}
+			return c.GetNativeAccountBalance(ctx, nativeChain, address, bs)
+		}
+	}
+	return c.GetERC20AccountBalance(ctx, chain, network, contract, address, bs)
+}
+
+// nativeChain returns the chain of native network. Networks not bound to a chain
+// resolve to the selected one, otherwise selector has to match network's chain.
+func (c *Client) nativeChain(selector, network string) (string, error) {
+	nc := c.nativeNetworks[strings.ToLower(network)]
+	if nc == "" {
+		return selector, nil
+	}
+	if selector != "" {
+		ch, err := c.chain(nc)
+		if err != nil {
+			return "", err
+		}
+		if !ch.Is(selector) {
+			return "", fmt.Errorf("%w: %s is on %s", ErrChainMismatch, network, ch)
+		}
+	}
+	return nc, nil
+}
+
+// GetNativeAccountBalance returns native currency balance of an account
+func (c *Client) GetNativeAccountBalance(ctx context.Context, chain, address string, bs structures.BlockSelector) ([]structures.Balance, error) {
+	timer := metrics.NewTimer(getNativeAccountBalanceDuration)
+	defer timer.ObserveDuration()
+
+	ch, err := c.chain(chain)
+	if err != nil {
+		return nil, err
+	}
+
+	bs, blk, err := c.resolveBlock(ctx, ch, bs)
+	if err != nil {
+		return nil, err
+	}
+
+	key := newResultKey(ResultNativeBalance, ch.ID, "", "", address, bs.Number)
+	if b, ok := c.getResult(key, bs); ok {
+		return b, nil
+	}
+
+	b, err := c.coalesce(ctx, key, bs, func(ctx context.Context) ([]structures.Balance, error) {
+		return c.getNativeAccountBalance(ctx, ch, address, bs, blk)
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.setResult(ctx, ch, key, bs, b)
+	return b, nil
+}
+
+// getNativeAccountBalance reads native balance at resolved block
+func (c *Client) getNativeAccountBalance(ctx context.Context, ch *Chain, address string, bs structures.BlockSelector, blk *structures.Block) ([]structures.Balance, error) {
+	ctxT, cancel := conn.WithCallTimeout(ctx, c.callTimeout)
+	defer cancel()
+
+	var (
+		balance *big.Int
+		err     error
+	)
+	if bs.Tag == structures.BlockPending {
+		balance, err = ch.t.PendingBalanceAt(ctxT, common.HexToAddress(address))
+	} else {
+		balance, err = ch.t.BalanceAt(ctxT, common.HexToAddress(address), new(big.Int).SetUint64(bs.Number))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error calling BalanceAt: %w", err)
+	}
+
+	return []structures.Balance{{
+		Values: structures.Values{
+			Value: *balance,
+			Type:  structures.TypeNative,
+		},
+		Details: ch.nativeDetails,
+		Block:   blk,
+	}}, nil
+}
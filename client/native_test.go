@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+func TestGetAccountBalanceNative(t *testing.T) {
+	const account = "0x00000000000000000000000000000000000000bb"
+
+	tests := []struct {
+		name    string
+		chain   string
+		network string
+		bs      structures.BlockSelector
+		typ     string
+		pending bool
+		err     error
+		calls   map[string]int
+	}{
+		{
+			name:    "native network",
+			network: "Ether",
+			bs:      structures.LatestBlock,
+			typ:     structures.TypeNative,
+			calls:   map[string]int{"BalanceAt": 1},
+		}, {
+			name:    "pending native balance",
+			network: "ether",
+			bs:      structures.BlockSelector{Tag: structures.BlockPending},
+			typ:     structures.TypeNative,
+			pending: true,
+			calls:   map[string]int{"PendingBalanceAt": 1},
+		}, {
+			name:    "native network bound to selected chain",
+			chain:   "goerli",
+			network: "goerlieth",
+			bs:      structures.NumberBlock(3),
+			typ:     structures.TypeNative,
+		}, {
+			name:    "native network bound to other chain",
+			chain:   "mainnet",
+			network: "goerlieth",
+			bs:      structures.LatestBlock,
+			err:     ErrChainMismatch,
+		}, {
+			name:    "registered ERC20 network takes precedence",
+			network: "token",
+			bs:      structures.LatestBlock,
+			typ:     structures.TypeERC20,
+			calls:   map[string]int{"BalanceAt": 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ft := newTestClient(&fakeERC20{balance: 5}, 10)
+			c.AddChain(5, "goerli", &fakeTransport{times: []uint64{0, 10, 20, 30}})
+			c.SetNativeNetworks([]string{"ether", "token"})
+			c.SetNativeNetwork("goerlieth", "5")
+			c.ccm.Set(1, "0xaa", "token", &ContractCache{Address: "0xaa", Chain: 1, BCC: fakeBCC{}})
+
+			b, err := c.GetAccountBalance(context.Background(), tt.chain, tt.network, "", account, tt.bs)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(b) != 1 || b[0].Values.Type != tt.typ {
+				t.Fatalf("balances = %+v, want one of type %s", b, tt.typ)
+			}
+			if tt.typ == structures.TypeNative && !reflect.DeepEqual(b[0].Details, EtherDetails) {
+				t.Errorf("details = %+v, want %+v", b[0].Details, EtherDetails)
+			}
+			if b[0].Block == nil || b[0].Block.Pending != tt.pending {
+				t.Errorf("block = %+v, want pending %v", b[0].Block, tt.pending)
+			}
+			for method, n := range tt.calls {
+				if got := ft.get(method); got != n {
+					t.Errorf("%s called %d times, want %d", method, got, n)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+	"github.com/figment-networks/ethereum-worker/api/erc20"
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+var (
+	ErrNetworkExists   = errors.New("network already exists")
+	ErrNetworkNotFound = errors.New("network not found")
+	ErrInvalidNetwork  = errors.New("contract is not a valid ERC20 token")
+)
+
+// SetNetworksFile enables persisting the network registry, every change made at runtime is written to the file
+func (c *Client) SetNetworksFile(path string) {
+	c.networksFile = path
+}
+
+// LoadNetworksFile registers networks persisted by the previous runs, the file holds the whole registry.
+// It returns false when the file does not exist yet.
+func (c *Client) LoadNetworksFile(ctx context.Context) (bool, error) {
+	data, err := ioutil.ReadFile(c.networksFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("error reading networks file: %w", err)
+	}
+
+	var nets []structures.Network
+	if err = json.Unmarshal(data, &nets); err != nil {
+		return false, fmt.Errorf("error decoding networks file: %w", err)
+	}
+
+	for _, n := range nets {
+		if err = c.LoadNetwork(ctx, n); err != nil {
+			return false, fmt.Errorf("error loading network %s: %w", n.Name, err)
+		}
+	}
+	return true, nil
+}
+
+// LoadNetwork registers ERC20 network under its name and aliases, on the default chain when network's chain is not set
+func (c *Client) LoadNetwork(ctx context.Context, n structures.Network) error {
+	c.networksL.Lock()
+	defer c.networksL.Unlock()
+
+	ch, err := c.chain(n.Chain)
+	if err != nil {
+		return err
+	}
+
+	cc, ok := c.storedContract(ch, n.Address)
+	if !ok {
+		cc = &ContractCache{Address: n.Address, Chain: ch.ID, BCC: ch.t.GetBoundContractCaller(common.HexToAddress(n.Address), c.erc20ABI)}
+		det, err := c.getERC20Details(ctx, cc.BCC, structures.LatestBlock)
+		if err != nil {
+			return fmt.Errorf("error calling getERC20Details: %w", err)
+		}
+		cc.Details = det
+		c.setContract(ch.ID, n.Address, "", cc)
+	}
+
+	c.registerNetwork(n, cc)
+	return nil
+}
+
+// ListNetworks returns registered networks ordered by name
+func (c *Client) ListNetworks() []structures.Network {
+	c.networksL.Lock()
+	defer c.networksL.Unlock()
+
+	return c.listNetworks()
+}
+
+// AddNetwork validates the contract and registers new network under its name and aliases,
+// empty chain selects the default chain
+func (c *Client) AddNetwork(ctx context.Context, n structures.Network) (structures.Network, error) {
+	c.networksL.Lock()
+	defer c.networksL.Unlock()
+
+	for _, name := range append([]string{n.Name}, n.Aliases...) {
+		if _, ok := c.ccm.GetByNetwork(name); ok {
+			return structures.Network{}, fmt.Errorf("%w: %s", ErrNetworkExists, name)
+		}
+	}
+	return c.putNetwork(ctx, n, nil)
+}
+
+// UpdateNetwork validates the contract and replaces definition of existing network.
+// Empty chain keeps the network's chain.
+func (c *Client) UpdateNetwork(ctx context.Context, n structures.Network) (structures.Network, error) {
+	c.networksL.Lock()
+	defer c.networksL.Unlock()
+
+	old, ok := c.networks[strings.ToLower(n.Name)]
+	if !ok {
+		return structures.Network{}, ErrNetworkNotFound
+	}
+	n.Name = old.Name
+	if n.Chain == "" {
+		n.Chain = old.Chain
+	}
+	for _, alias := range n.Aliases {
+		if owner, ok := c.networkOf(alias); ok && !strings.EqualFold(owner, old.Name) {
+			return structures.Network{}, fmt.Errorf("%w: %s", ErrNetworkExists, alias)
+		}
+	}
+	return c.putNetwork(ctx, n, &old)
+}
+
+// RemoveNetwork unregisters network with its aliases
+func (c *Client) RemoveNetwork(name string) error {
+	c.networksL.Lock()
+	defer c.networksL.Unlock()
+
+	n, ok := c.networks[strings.ToLower(name)]
+	if !ok {
+		return ErrNetworkNotFound
+	}
+	c.unregisterNetwork(n)
+	return c.saveNetworks()
+}
+
+// networkOf returns the name of registered network using the name as its name or alias.
+// It has to be called under networksL lock.
+func (c *Client) networkOf(name string) (string, bool) {
+	for _, n := range c.networks {
+		for _, alias := range append([]string{n.Name}, n.Aliases...) {
+			if strings.EqualFold(alias, name) {
+				return n.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// registerNetwork maps network name and aliases to the contract. Overrides are applied
+// to the network's copy only, so the details persisted in store stay as read from the contract.
+// It has to be called under networksL lock.
+func (c *Client) registerNetwork(n structures.Network, contract *ContractCache) {
+	cc := &ContractCache{
+		Address:    n.Address,
+		Chain:      contract.Chain,
+		BCC:        contract.BCC,
+		Details:    n.Overrides.Apply(contract.Details),
+		StartBlock: n.StartBlock,
+	}
+
+	c.ccm.Set(cc.Chain, n.Address, n.Name, cc)
+	for _, alias := range n.Aliases {
+		c.ccm.Set(cc.Chain, n.Address, alias, cc)
+	}
+
+	n.Details = cc.Details
+	c.networks[strings.ToLower(n.Name)] = n
+}
+
+// unregisterNetwork removes network name and aliases mapping, it has to be called under networksL lock
+func (c *Client) unregisterNetwork(n structures.Network) {
+	for _, alias := range append([]string{n.Name}, n.Aliases...) {
+		c.ccm.RemoveNetwork(alias)
+	}
+	delete(c.networks, strings.ToLower(n.Name))
+}
+
+// listNetworks has to be called under networksL lock
+func (c *Client) listNetworks() []structures.Network {
+	list := make([]structures.Network, 0, len(c.networks))
+	for _, n := range c.networks {
+		list = append(list, n)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// putNetwork registers the network replacing the old definition when set. It has to be called under networksL lock.
+func (c *Client) putNetwork(ctx context.Context, n structures.Network, old *structures.Network) (structures.Network, error) {
+	if !common.IsHexAddress(n.Address) {
+		return structures.Network{}, fmt.Errorf("%w: invalid address %q", ErrInvalidNetwork, n.Address)
+	}
+
+	ch, err := c.chain(n.Chain)
+	if err != nil {
+		return structures.Network{}, err
+	}
+
+	cc, err := c.validateNetwork(ctx, ch, n.Address)
+	if err != nil {
+		return structures.Network{}, err
+	}
+	c.setContract(ch.ID, n.Address, "", cc)
+	if old != nil {
+		c.unregisterNetwork(*old)
+	}
+	c.registerNetwork(n, cc)
+
+	n = c.networks[strings.ToLower(n.Name)]
+	if err = c.saveNetworks(); err != nil {
+		return n, fmt.Errorf("network is active, but it was not persisted: %w", err)
+	}
+	return n, nil
+}
+
+// validateNetwork checks the contract implements ERC20 totalSupply and decimals at the latest block
+func (c *Client) validateNetwork(ctx context.Context, ch *Chain, address string) (*ContractCache, error) {
+	cc := &ContractCache{Address: address, Chain: ch.ID, BCC: ch.t.GetBoundContractCaller(common.HexToAddress(address), c.erc20ABI)}
+
+	if _, err := c.serverApi.TotalSupply(ctx, cc.BCC.GetContract(), structures.LatestBlock); err != nil {
+		if errors.Is(err, bind.ErrNoCode) || conn.IsContractError(err) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidNetwork, err.Error())
+		}
+		return nil, fmt.Errorf("error calling TotalSupply: %w", err)
+	}
+
+	det, err := c.getERC20Details(ctx, cc.BCC, structures.LatestBlock)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range det.Unavailable {
+		if f == erc20.FieldDecimals {
+			return nil, fmt.Errorf("%w: decimals are not available", ErrInvalidNetwork)
+		}
+	}
+	cc.Details = det
+	return cc, nil
+}
+
+// saveNetworks writes the registry to the temporary file first, so the file is never left partially written
+func (c *Client) saveNetworks() error {
+	if c.networksFile == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(c.listNetworks(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(c.networksFile), filepath.Base(c.networksFile)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.networksFile)
+}
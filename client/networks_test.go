@@ -0,0 +1,175 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+func TestNetworkRegistry(t *testing.T) {
+	const (
+		contractA = "0x00000000000000000000000000000000000000aa"
+		contractB = "0x00000000000000000000000000000000000000ab"
+		account   = "0x00000000000000000000000000000000000000bb"
+	)
+	decimals := uint64(8)
+	details := structures.Details{Name: "Token", Symbol: "TKN", Decimals: 18}
+	overridden := structures.Details{Name: "Token", Symbol: "TKN", Decimals: 8}
+
+	api := &fakeERC20{balance: 5, details: details}
+	c, ft := newTestClient(api, 20)
+	goerli := &fakeTransport{times: ft.times}
+	c.AddChain(5, "goerli", goerli)
+	finalized := uint64(15)
+	ft.finalized = &finalized
+	c.SetResultCache(NewResultCache(10))
+
+	n, err := c.AddNetwork(context.Background(), structures.Network{
+		Name:       "token",
+		Aliases:    []string{"tkn"},
+		Address:    contractA,
+		StartBlock: 3,
+		Overrides:  &structures.DetailsOverrides{Decimals: &decimals},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(n.Details, overridden) || n.StartBlock != 3 || len(n.Aliases) != 1 {
+		t.Errorf("network = %+v", n)
+	}
+
+	balance := func(network string, height uint64) (int64, structures.Details) {
+		t.Helper()
+		b, err := c.GetERC20AccountBalance(context.Background(), "", network, "", account, structures.NumberBlock(height))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return b[0].Values.Value.Int64(), b[0].Details
+	}
+
+	if v, det := balance("tkn", 11); v != 5 || !reflect.DeepEqual(det, overridden) {
+		t.Errorf("balance by alias = %d %+v, want 5 with overridden details", v, det)
+	}
+	if v, _ := balance("token", 2); v != 0 {
+		t.Errorf("balance before start block = %d, want 0", v)
+	}
+
+	// the balance at finalized block is cached
+	balance("token", 10)
+	api.balance = 9
+	if v, _ := balance("token", 10); v != 5 {
+		t.Errorf("cached balance = %d, want 5", v)
+	}
+
+	tests := []struct {
+		name string
+		add  bool
+		n    structures.Network
+		err  error
+	}{
+		{name: "add existing name", add: true, n: structures.Network{Name: "TOKEN", Address: contractB}, err: ErrNetworkExists},
+		{name: "add existing alias", add: true, n: structures.Network{Name: "other", Aliases: []string{"tkn"}, Address: contractB}, err: ErrNetworkExists},
+		{name: "add alias of existing name", add: true, n: structures.Network{Name: "other", Aliases: []string{"token"}, Address: contractB}, err: ErrNetworkExists},
+		{name: "add invalid address", add: true, n: structures.Network{Name: "other", Address: "0xinvalid"}, err: ErrInvalidNetwork},
+		{name: "add unknown chain", add: true, n: structures.Network{Name: "other", Address: contractB, Chain: "sepolia"}, err: ErrUnknownChain},
+		{name: "update unknown network", n: structures.Network{Name: "other", Address: contractB}, err: ErrNetworkNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.add {
+				_, err = c.AddNetwork(context.Background(), tt.n)
+			} else {
+				_, err = c.UpdateNetwork(context.Background(), tt.n)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+
+	if _, err = c.AddNetwork(context.Background(), structures.Network{Name: "other", Aliases: []string{"oth"}, Address: contractB}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err = c.UpdateNetwork(context.Background(), structures.Network{Name: "token", Aliases: []string{"oth"}, Address: contractB}); !errors.Is(err, ErrNetworkExists) {
+		t.Errorf("update to alias of other network error = %v, want %v", err, ErrNetworkExists)
+	}
+
+	// update replaces the definition, results read at the previous contract are not returned anymore
+	n, err = c.UpdateNetwork(context.Background(), structures.Network{Name: "Token", Aliases: []string{"tok"}, Address: contractB})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n.Name != "token" || n.Address != contractB || n.StartBlock != 0 || !reflect.DeepEqual(n.Details, details) {
+		t.Errorf("updated network = %+v", n)
+	}
+	if v, det := balance("token", 10); v != 9 || !reflect.DeepEqual(det, details) {
+		t.Errorf("balance after update = %d %+v, want 9 with contract details", v, det)
+	}
+	if v, _ := balance("tok", 2); v != 9 {
+		t.Errorf("balance by new alias = %d, want 9", v)
+	}
+	if _, ok := c.ccm.GetByNetwork("tkn"); ok {
+		t.Error("previous alias is still registered")
+	}
+
+	// chain is kept unless set
+	if n, err = c.UpdateNetwork(context.Background(), structures.Network{Name: "token", Address: contractB, Chain: "goerli"}); err != nil || n.Chain != "goerli" {
+		t.Fatalf("update chain = %+v, %v", n, err)
+	}
+	if n, err = c.UpdateNetwork(context.Background(), structures.Network{Name: "token", Address: contractB}); err != nil || n.Chain != "goerli" {
+		t.Errorf("update without chain = %+v, %v, want chain kept", n, err)
+	}
+
+	if err = c.RemoveNetwork("token"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = c.RemoveNetwork("token"); !errors.Is(err, ErrNetworkNotFound) {
+		t.Errorf("second remove error = %v, want %v", err, ErrNetworkNotFound)
+	}
+	for _, name := range []string{"token", "tok"} {
+		if _, ok := c.ccm.GetByNetwork(name); ok {
+			t.Errorf("%s is still registered", name)
+		}
+	}
+	if got := c.ListNetworks(); len(got) != 1 || got[0].Name != "other" {
+		t.Errorf("networks = %+v, want only other", got)
+	}
+}
+
+func TestNetworksFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "networks.json")
+	api := &fakeERC20{details: structures.Details{Name: "Token", Symbol: "TKN", Decimals: 18}}
+
+	c, _ := newTestClient(api, 10)
+	c.SetNetworksFile(path)
+	if ok, err := c.LoadNetworksFile(context.Background()); ok || err != nil {
+		t.Fatalf("load of missing file = %v, %v", ok, err)
+	}
+	// configured networks
+	for _, name := range []string{"a", "b"} {
+		if err := c.LoadNetwork(context.Background(), structures.Network{Name: name, Address: "0x00000000000000000000000000000000000000aa"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := c.RemoveNetwork("a"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// after restart the file holds the whole registry, removed network stays removed
+	c, _ = newTestClient(api, 10)
+	c.SetNetworksFile(path)
+	if ok, err := c.LoadNetworksFile(context.Background()); !ok || err != nil {
+		t.Fatalf("load = %v, %v", ok, err)
+	}
+	var names []string
+	for _, n := range c.ListNetworks() {
+		names = append(names, n.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"b"}) {
+		t.Errorf("networks = %v, want [b]", names)
+	}
+}
@@ -0,0 +1,216 @@
+package client
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+// Kinds of cached results
+const (
+	ResultERC20Balance     = "erc20Balance"
+	ResultNativeBalance    = "nativeBalance"
+	ResultERC20TotalSupply = "erc20TotalSupply"
+)
+
+// finalizedRefresh is how long finalized head is reused before asking the node again
+const finalizedRefresh = 12 * time.Second
+
+// ResultKey identifies a cached result
+type ResultKey struct {
+	Kind     string
+	Chain    uint64
+	Network  string
+	Contract string
+	Account  string
+	Height   uint64
+}
+
+func newResultKey(kind string, chain uint64, network, contract, account string, height uint64) ResultKey {
+	return ResultKey{
+		Kind:     kind,
+		Chain:    chain,
+		Network:  strings.ToLower(network),
+		Contract: strings.ToLower(contract),
+		Account:  strings.ToLower(account),
+		Height:   height,
+	}
+}
+
+// erc20ResultKey keys results of registered network by the contract the network is mapped to now, so results
+// read before the network was updated are never returned. Current details of the network are returned with the key.
+func (c *Client) erc20ResultKey(kind string, ch *Chain, network, contract, account string, height uint64) (ResultKey, *structures.Details) {
+	if network != "" {
+		if cc, ok := c.ccm.GetByNetwork(network); ok {
+			details := cc.Details
+			return newResultKey(kind, ch.ID, network, cc.Address, account, height), &details
+		}
+	}
+	return newResultKey(kind, ch.ID, network, contract, account, height), nil
+}
+
+// setDetails replaces details of cached result with the current ones, when they are known
+func setDetails(bals []structures.Balance, details *structures.Details) []structures.Balance {
+	if details != nil {
+		for i := range bals {
+			bals[i].Details = *details
+		}
+	}
+	return bals
+}
+
+type resultEntry struct {
+	key      ResultKey
+	balances []structures.Balance
+}
+
+// ResultCache is a size bounded LRU cache of results read at finalized blocks.
+// State of finalized blocks never changes, so entries do not expire.
+type ResultCache struct {
+	l       sync.Mutex
+	size    int
+	entries map[ResultKey]*list.Element
+	lru     *list.List
+}
+
+// NewResultCache is ResultCache constructor, size is the maximum number of entries
+func NewResultCache(size int) *ResultCache {
+	return &ResultCache{
+		size:    size,
+		entries: make(map[ResultKey]*list.Element, size),
+		lru:     list.New(),
+	}
+}
+
+// Get returns a copy of cached result
+func (rc *ResultCache) Get(key ResultKey) ([]structures.Balance, bool) {
+	rc.l.Lock()
+	defer rc.l.Unlock()
+
+	el, ok := rc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	rc.lru.MoveToFront(el)
+	return append([]structures.Balance(nil), el.Value.(*resultEntry).balances...), true
+}
+
+// Set stores a copy of the result, evicting the least recently used one when cache is full
+func (rc *ResultCache) Set(key ResultKey, balances []structures.Balance) {
+	balances = append([]structures.Balance(nil), balances...)
+
+	rc.l.Lock()
+	defer rc.l.Unlock()
+
+	if el, ok := rc.entries[key]; ok {
+		el.Value.(*resultEntry).balances = balances
+		rc.lru.MoveToFront(el)
+		return
+	}
+
+	rc.entries[key] = rc.lru.PushFront(&resultEntry{key: key, balances: balances})
+	for rc.lru.Len() > rc.size {
+		oldest := rc.lru.Back()
+		rc.lru.Remove(oldest)
+		delete(rc.entries, oldest.Value.(*resultEntry).key)
+	}
+}
+
+// SetResultCache enables caching of results read at finalized blocks
+func (c *Client) SetResultCache(rc *ResultCache) {
+	c.rc = rc
+}
+
+// getResult looks up cached result, then the persisted one. Only explicit block numbers are cached.
+func (c *Client) getResult(key ResultKey, bs structures.BlockSelector) (b []structures.Balance, ok bool) {
+	if c.rc == nil && c.store == nil || bs.Tag != structures.BlockNumber {
+		return nil, false
+	}
+
+	if c.rc != nil {
+		b, ok = c.rc.Get(key)
+	}
+	if !ok {
+		if b, ok = c.storedResult(key); ok && c.rc != nil {
+			c.rc.Set(key, b)
+		}
+	}
+	if ok {
+		resultCacheLookups.WithLabels(key.Kind, "hit").Inc()
+	} else {
+		resultCacheLookups.WithLabels(key.Kind, "miss").Inc()
+	}
+	return b, ok
+}
+
+// setResult caches and persists the result if it was read at or below the finalized head
+func (c *Client) setResult(ctx context.Context, ch *Chain, key ResultKey, bs structures.BlockSelector, balances []structures.Balance) {
+	if c.rc == nil && c.store == nil || bs.Tag != structures.BlockNumber {
+		return
+	}
+
+	finalized, err := ch.finalizedHeight(ctx, c.callTimeout)
+	if err != nil || bs.Number > finalized {
+		return
+	}
+	if c.rc != nil {
+		c.rc.Set(key, balances)
+	}
+	c.storeResult(key, balances)
+}
+
+// finalizedHeight returns the chain's finalized head. Nodes not supporting finalized tag
+// are assumed to finalize blocks confirmationDepth behind the latest one, other errors are returned.
+// Subscribed transports refresh it when a new head arrives, the others every finalizedRefresh.
+// Concurrent refreshes share a single node read, which is done outside the lock.
+func (ch *Chain) finalizedHeight(ctx context.Context, timeout time.Duration) (uint64, error) {
+	head := ch.t.Head()
+
+	ch.finalizedL.Lock()
+	fresh := time.Since(ch.finalizedChecked) < finalizedRefresh
+	if head != nil {
+		fresh = !ch.finalizedChecked.IsZero() && head.Number.Uint64() == ch.finalizedHead
+	}
+	if fresh {
+		defer ch.finalizedL.Unlock()
+		return ch.finalized, nil
+	}
+	ch.finalizedL.Unlock()
+
+	v, err := shareCall(ctx, &ch.finalizedSF, "finalized", timeout, func(ctx context.Context) (interface{}, error) {
+		h, err := ch.t.HeaderByTag(ctx, structures.BlockSelector{Tag: structures.BlockFinalized}.String())
+		if err == nil {
+			return h.Number.Uint64(), nil
+		}
+		// results are not cached when finalized head is not known for sure
+		if !conn.IsTagUnsupported(err) {
+			return nil, err
+		}
+		if h = head; h == nil {
+			if h, err = ch.t.HeaderByNumber(ctx, nil); err != nil {
+				return nil, err
+			}
+		}
+		if h.Number.Uint64() < confirmationDepth {
+			return uint64(0), nil
+		}
+		return h.Number.Uint64() - confirmationDepth, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	ch.finalizedL.Lock()
+	defer ch.finalizedL.Unlock()
+	ch.finalized = v.(uint64)
+	ch.finalizedChecked = time.Now()
+	if head != nil {
+		ch.finalizedHead = head.Number.Uint64()
+	}
+	return ch.finalized, nil
+}
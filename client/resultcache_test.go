@@ -0,0 +1,199 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+func balances(v int64) []structures.Balance {
+	return []structures.Balance{{Values: structures.Values{Value: *big.NewInt(v), Type: structures.TypeERC20}}}
+}
+
+func TestResultCacheLRU(t *testing.T) {
+	key := func(height uint64) ResultKey {
+		return newResultKey(ResultERC20Balance, 1, "", "0xAA", "0xbb", height)
+	}
+
+	rc := NewResultCache(2)
+	rc.Set(key(1), balances(1))
+	rc.Set(key(2), balances(2))
+	if _, ok := rc.Get(key(1)); !ok {
+		t.Fatal("entry 1 missing")
+	}
+	// entry 2 is the least recently used now
+	rc.Set(key(3), balances(3))
+
+	for _, tt := range []struct {
+		height uint64
+		ok     bool
+	}{{1, true}, {2, false}, {3, true}} {
+		b, ok := rc.Get(key(tt.height))
+		if ok != tt.ok {
+			t.Errorf("Get(%d) found = %v, want %v", tt.height, ok, tt.ok)
+		}
+		if ok && b[0].Values.Value.Int64() != int64(tt.height) {
+			t.Errorf("Get(%d) = %v", tt.height, b)
+		}
+	}
+
+	// keys are case insensitive
+	if _, ok := rc.Get(newResultKey(ResultERC20Balance, 1, "", "0xaa", "0xBB", 1)); !ok {
+		t.Error("entry 1 missing by lower case key")
+	}
+
+	// callers get their own copy
+	b, _ := rc.Get(key(1))
+	b[0] = balances(100)[0]
+	if b, _ = rc.Get(key(1)); b[0].Values.Value.Int64() != 1 {
+		t.Errorf("cached entry modified through returned slice: %v", b)
+	}
+}
+
+func TestSetResult(t *testing.T) {
+	finalized := uint64(5)
+
+	tests := []struct {
+		name      string
+		bs        structures.BlockSelector
+		finalized *uint64
+		tagErr    error
+		cached    bool
+	}{
+		{name: "finalized block", bs: structures.NumberBlock(5), finalized: &finalized, cached: true},
+		{name: "block after finalized", bs: structures.NumberBlock(6), finalized: &finalized},
+		{name: "latest tag", bs: structures.LatestBlock, finalized: &finalized},
+		{name: "older than confirmation depth", bs: structures.NumberBlock(100 - confirmationDepth - 1), cached: true},
+		{name: "at confirmation depth", bs: structures.NumberBlock(100 - confirmationDepth), cached: true},
+		{name: "newer than confirmation depth", bs: structures.NumberBlock(100 - confirmationDepth + 1)},
+		{name: "finalized head unavailable", bs: structures.NumberBlock(1), tagErr: rpc.HTTPError{StatusCode: http.StatusTooManyRequests}},
+		{name: "finalized head timed out", bs: structures.NumberBlock(1), tagErr: context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ft := newTestClient(&fakeERC20{}, 101)
+			ft.finalized = tt.finalized
+			ft.tagErr = tt.tagErr
+			c.SetResultCache(NewResultCache(10))
+
+			key := newResultKey(ResultERC20Balance, 1, "", "0xaa", "0xbb", tt.bs.Number)
+			c.setResult(context.Background(), c.Chains()[0], key, tt.bs, balances(1))
+			if _, ok := c.getResult(key, tt.bs); ok != tt.cached {
+				t.Errorf("cached = %v, want %v", ok, tt.cached)
+			}
+		})
+	}
+}
+
+// blockingFinalized blocks finalized head reads until release is closed
+type blockingFinalized struct {
+	*fakeTransport
+	started chan struct{}
+	release chan struct{}
+}
+
+func (bf *blockingFinalized) HeaderByTag(ctx context.Context, tag string) (*types.Header, error) {
+	bf.started <- struct{}{}
+	<-bf.release
+	return bf.fakeTransport.HeaderByTag(ctx, tag)
+}
+
+func TestFinalizedHeightShared(t *testing.T) {
+	finalized := uint64(5)
+	_, ft := newTestClient(&fakeERC20{}, 10)
+	ft.finalized = &finalized
+	bf := &blockingFinalized{fakeTransport: ft, started: make(chan struct{}, 10), release: make(chan struct{})}
+	ch := &Chain{t: bf}
+
+	const callers = 5
+	var wg sync.WaitGroup
+	results := make(chan uint64, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h, err := ch.finalizedHeight(context.Background(), 0)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			results <- h
+		}()
+	}
+
+	<-bf.started
+	// the lock is free while the node is read
+	ch.finalizedL.Lock()
+	ch.finalizedL.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+	close(bf.release)
+	wg.Wait()
+	close(results)
+
+	for h := range results {
+		if h != finalized {
+			t.Errorf("finalized = %d, want %d", h, finalized)
+		}
+	}
+	// callers arriving after the read finished may start another one, but never one each
+	if n := ft.get("HeaderByTag"); n == 0 || n >= callers {
+		t.Errorf("node read %d times for %d callers", n, callers)
+	}
+
+	// fresh value is served without asking the node
+	n := ft.get("HeaderByTag")
+	if _, err := ch.finalizedHeight(context.Background(), 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ft.get("HeaderByTag") != n {
+		t.Error("fresh finalized head read from the node again")
+	}
+}
+
+func TestFinalizedHeightHeads(t *testing.T) {
+	tests := []struct {
+		name      string
+		finalized *uint64
+		want      []uint64
+		tagCalls  int
+	}{
+		{name: "finalized tag", finalized: func() *uint64 { f := uint64(50); return &f }(), want: []uint64{50, 50, 50}, tagCalls: 2},
+		{name: "confirmed behind head", want: []uint64{100 - confirmationDepth, 100 - confirmationDepth, 101 - confirmationDepth}, tagCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ft := newTestClient(&fakeERC20{}, 102)
+			ft.finalized = tt.finalized
+			ch := &Chain{t: ft}
+
+			// the same head is read once, the next one refreshes finalized head
+			heads := []int64{100, 100, 101}
+			for i, head := range heads {
+				ft.head = &types.Header{Number: big.NewInt(head)}
+				f, err := ch.finalizedHeight(context.Background(), 0)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if f != tt.want[i] {
+					t.Errorf("finalized at head %d = %d, want %d", head, f, tt.want[i])
+				}
+			}
+			if n := ft.get("HeaderByTag"); n != tt.tagCalls {
+				t.Errorf("finalized tag read %d times, want %d", n, tt.tagCalls)
+			}
+			if n := ft.get("HeaderByNumber"); n != 0 {
+				t.Errorf("latest header read %d times, want subscribed head used", n)
+			}
+		})
+	}
+}
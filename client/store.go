@@ -0,0 +1,87 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+
+	"github.com/figment-networks/ethereum-worker/store"
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+// SetStore enables persisting token details and finalized results, so they survive restarts
+func (c *Client) SetStore(s store.Store) {
+	c.store = s
+}
+
+func (k ResultKey) storeKey() []byte {
+	return []byte(fmt.Sprintf("%s|%d|%s|%s|%s|%d", k.Kind, k.Chain, k.Network, k.Contract, k.Account, k.Height))
+}
+
+// storedContract returns ERC20 contract with details persisted by the previous run. Details with none
+// of the fields available, persisted by earlier versions, are read from the node again.
+func (c *Client) storedContract(ch *Chain, contract string) (*ContractCache, bool) {
+	if c.store == nil {
+		return nil, false
+	}
+
+	var det structures.Details
+	if !c.storeGet(store.BucketDetails, []byte(chainKey(ch.ID, contract)), &det) || det.Unknown() {
+		return nil, false
+	}
+
+	cc := &ContractCache{Address: contract, Chain: ch.ID, BCC: ch.t.GetBoundContractCaller(common.HexToAddress(contract), c.erc20ABI), Details: det}
+	c.ccm.Set(ch.ID, contract, "", cc)
+	return cc, true
+}
+
+// setContract caches ERC20 contract and persists its details
+func (c *Client) setContract(chain uint64, contract, network string, cc *ContractCache) {
+	c.ccm.Set(chain, contract, network, cc)
+	if c.store != nil {
+		c.storePut(store.BucketDetails, []byte(chainKey(chain, contract)), cc.Details)
+	}
+}
+
+func (c *Client) storedResult(key ResultKey) ([]structures.Balance, bool) {
+	if c.store == nil {
+		return nil, false
+	}
+	var b []structures.Balance
+	return b, c.storeGet(store.BucketResults, key.storeKey(), &b)
+}
+
+func (c *Client) storeResult(key ResultKey, b []structures.Balance) {
+	if c.store != nil {
+		c.storePut(store.BucketResults, key.storeKey(), b)
+	}
+}
+
+// storeGet decodes stored value. Store errors are only logged, as the value can always be fetched from the node.
+func (c *Client) storeGet(bucket, key []byte, v interface{}) bool {
+	data, err := c.store.Get(bucket, key)
+	if err != nil {
+		if !errors.Is(err, store.ErrNotFound) {
+			c.log.Warn("Error reading from store", zap.ByteString("bucket", bucket), zap.Error(err))
+		}
+		return false
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		c.log.Warn("Error decoding stored value", zap.ByteString("bucket", bucket), zap.ByteString("key", key), zap.Error(err))
+		return false
+	}
+	return true
+}
+
+func (c *Client) storePut(bucket, key []byte, v interface{}) {
+	data, err := json.Marshal(v)
+	if err == nil {
+		err = c.store.Put(bucket, key, data)
+	}
+	if err != nil {
+		c.log.Warn("Error writing to store", zap.ByteString("bucket", bucket), zap.Error(err))
+	}
+}
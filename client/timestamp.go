@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/figment-networks/ethereum-worker/structures"
+	"github.com/figment-networks/indexing-engine/metrics"
+)
+
+var (
+	ErrTimestampBeforeGenesis = errors.New("timestamp is before the genesis block")
+	ErrTimestampInFuture      = errors.New("timestamp is in the future")
+)
+
+// confirmationDepth is the number of blocks after which block is treated as final
+const confirmationDepth = 64
+
+// maxBlockTimeCacheSize bounds the number of cached block times and timestamp resolutions
+const maxBlockTimeCacheSize = 100000
+
+var getBlockAtTimestampDuration *metrics.GroupObserver
+
+// BlockTimeCache keeps block times and timestamp resolutions of final blocks
+type BlockTimeCache struct {
+	l          sync.RWMutex
+	blockTimes map[uint64]uint64
+	timestamps map[int64]structures.Block
+}
+
+func NewBlockTimeCache() *BlockTimeCache {
+	return &BlockTimeCache{blockTimes: make(map[uint64]uint64), timestamps: make(map[int64]structures.Block)}
+}
+
+func (btc *BlockTimeCache) GetBlockTime(height uint64) (uint64, bool) {
+	btc.l.RLock()
+	defer btc.l.RUnlock()
+	t, ok := btc.blockTimes[height]
+	return t, ok
+}
+
+func (btc *BlockTimeCache) SetBlockTime(height, t uint64) {
+	btc.l.Lock()
+	defer btc.l.Unlock()
+	if len(btc.blockTimes) >= maxBlockTimeCacheSize {
+		btc.blockTimes = make(map[uint64]uint64)
+	}
+	btc.blockTimes[height] = t
+}
+
+func (btc *BlockTimeCache) GetTimestamp(ts int64) (structures.Block, bool) {
+	btc.l.RLock()
+	defer btc.l.RUnlock()
+	b, ok := btc.timestamps[ts]
+	return b, ok
+}
+
+func (btc *BlockTimeCache) SetTimestamp(ts int64, b structures.Block) {
+	btc.l.Lock()
+	defer btc.l.Unlock()
+	if len(btc.timestamps) >= maxBlockTimeCacheSize {
+		btc.timestamps = make(map[int64]structures.Block)
+	}
+	btc.timestamps[ts] = b
+}
+
+// GetBlockAtTimestamp returns the last block of the chain produced at or before given time.
+// When chain is not set, the chain network is registered on is used.
+func (c *Client) GetBlockAtTimestamp(ctx context.Context, chain, network string, ts time.Time) (structures.Block, error) {
+	timer := metrics.NewTimer(getBlockAtTimestampDuration)
+	defer timer.ObserveDuration()
+
+	if ts.After(time.Now()) {
+		return structures.Block{}, ErrTimestampInFuture
+	}
+
+	ch, err := c.resolveChain(chain, network)
+	if err != nil {
+		return structures.Block{}, err
+	}
+
+	unix := ts.Unix()
+	if b, ok := ch.btc.GetTimestamp(unix); ok {
+		return b, nil
+	}
+
+	latest, err := ch.t.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return structures.Block{}, fmt.Errorf("error getting latest header: %w", err)
+	}
+	latestHeight := latest.Number.Uint64()
+
+	if latest.Time <= uint64(unix) {
+		// latest block may still change, so it is not cached
+		t := time.Unix(int64(latest.Time), 0).UTC()
+		return structures.Block{Height: latestHeight, Time: &t}, nil
+	}
+
+	genesisTime, err := c.getBlockTime(ctx, ch, 0, latestHeight)
+	if err != nil {
+		return structures.Block{}, err
+	}
+	if genesisTime > uint64(unix) {
+		return structures.Block{}, ErrTimestampBeforeGenesis
+	}
+
+	// binary search for the last block with time <= ts, invariant: time(lo) <= ts < time(hi)
+	lo, hi := uint64(0), latestHeight
+	loTime := genesisTime
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		t, err := c.getBlockTime(ctx, ch, mid, latestHeight)
+		if err != nil {
+			return structures.Block{}, err
+		}
+		if t <= uint64(unix) {
+			lo, loTime = mid, t
+		} else {
+			hi = mid
+		}
+	}
+
+	t := time.Unix(int64(loTime), 0).UTC()
+	b := structures.Block{Height: lo, Time: &t}
+	// the answer is final only if the next block is final as well
+	if latestHeight-hi >= confirmationDepth {
+		ch.btc.SetTimestamp(unix, b)
+	}
+	return b, nil
+}
+
+func (c *Client) getBlockTime(ctx context.Context, ch *Chain, height, latestHeight uint64) (uint64, error) {
+	if t, ok := ch.btc.GetBlockTime(height); ok {
+		return t, nil
+	}
+
+	h, err := ch.t.HeaderByNumber(ctx, new(big.Int).SetUint64(height))
+	if err != nil {
+		return 0, fmt.Errorf("error getting header %d: %w", height, err)
+	}
+
+	if latestHeight-height >= confirmationDepth {
+		ch.btc.SetBlockTime(height, h.Time)
+	}
+	return h.Time, nil
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGetBlockAtTimestamp(t *testing.T) {
+	// 200 blocks produced every 10s since 1000
+	const blocks = 200
+
+	tests := []struct {
+		name   string
+		ts     time.Time
+		height uint64
+		err    error
+		cached bool
+	}{
+		{name: "block time", ts: time.Unix(1500, 0), height: 50, cached: true},
+		{name: "between blocks", ts: time.Unix(1505, 0), height: 50, cached: true},
+		{name: "just before next block", ts: time.Unix(1509, 0), height: 50, cached: true},
+		{name: "genesis", ts: time.Unix(1000, 0), height: 0, cached: true},
+		{name: "not final yet", ts: time.Unix(2900, 0), height: 190},
+		{name: "latest block", ts: time.Unix(1000+(blocks-1)*10, 0), height: blocks - 1},
+		{name: "after latest block", ts: time.Unix(100000, 0), height: blocks - 1},
+		{name: "before genesis", ts: time.Unix(999, 0), err: ErrTimestampBeforeGenesis},
+		{name: "in the future", ts: time.Now().Add(time.Hour), err: ErrTimestampInFuture},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ft := newTestClient(&fakeERC20{}, blocks)
+			for i := range ft.times {
+				ft.times[i] = 1000 + uint64(i)*10
+			}
+
+			b, err := c.GetBlockAtTimestamp(context.Background(), "", "", tt.ts)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if b.Height != tt.height || b.Time == nil || b.Time.Unix() != int64(ft.times[tt.height]) {
+				t.Errorf("block = %+v, want height %d", b, tt.height)
+			}
+
+			calls := ft.get("HeaderByNumber")
+			if _, err = c.GetBlockAtTimestamp(context.Background(), "", "", tt.ts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cached := ft.get("HeaderByNumber") == calls; cached != tt.cached {
+				t.Errorf("cached = %v, want %v", cached, tt.cached)
+			}
+		})
+	}
+}
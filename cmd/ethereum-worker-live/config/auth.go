@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// APIKey is an api key entry of the config file
+type APIKey struct {
+	ID  string `json:"id"`
+	Key string `json:"key"`
+	// Secret makes HMAC signature of requests required
+	Secret string `json:"secret"`
+	// RPS and Burst default to auth_rate_limit and auth_rate_burst
+	RPS   float64 `json:"rps"`
+	Burst int     `json:"burst"`
+	// Scopes are api, admin, health and metrics, api when not set
+	Scopes []string `json:"scopes"`
+}
+
+// APIKeyList returns api keys of the config file followed by the ones given as id:key[:secret] in AuthAPIKeys
+func (c *Config) APIKeyList() ([]APIKey, error) {
+	keys := make([]APIKey, 0, len(c.APIKeys)+len(c.AuthAPIKeys))
+	keys = append(keys, c.APIKeys...)
+	for _, k := range c.AuthAPIKeys {
+		parts := strings.Split(k, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("auth_api_keys entry has to be id:key or id:key:secret")
+		}
+		ak := APIKey{ID: parts[0], Key: parts[1]}
+		if len(parts) == 3 {
+			ak.Secret = parts[2]
+		}
+		keys = append(keys, ak)
+	}
+
+	for i := range keys {
+		if keys[i].RPS == 0 {
+			keys[i].RPS = c.AuthRateLimit
+		}
+		if keys[i].Burst == 0 {
+			keys[i].Burst = c.AuthRateBurst
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Chain is a chain entry of the config file, every chain is served by its own nodes
+type Chain struct {
+	// ID is the chain id, e.g. 1 for Ethereum mainnet
+	ID   uint64 `json:"id"`
+	Name string `json:"name"`
+	// Genesis is the expected genesis block hash, it is not verified when empty
+	Genesis string `json:"genesis"`
+
+	EthereumAddress   string   `json:"ethereum_address"`
+	EthereumAddresses []string `json:"ethereum_addresses"`
+	// MulticallAddress defaults to the top level multicall_address
+	MulticallAddress string `json:"multicall_address"`
+	// Native are details of the chain's native currency, Ether when not set
+	Native *NativeCurrency `json:"native"`
+}
+
+// NativeCurrency are details of chain's native currency
+type NativeCurrency struct {
+	Name     string `json:"name"`
+	Symbol   string `json:"symbol"`
+	Decimals uint64 `json:"decimals"`
+}
+
+// ChainList returns configured chains, the first one is the default chain. Without chains section
+// single chain is made of top level node settings, when its id is not set (0) it has to be read from the node.
+func (c *Config) ChainList() ([]Chain, error) {
+	if len(c.Chains) == 0 {
+		ch := Chain{
+			ID:                c.EthereumChainID,
+			Genesis:           c.EthereumGenesisHash,
+			EthereumAddress:   c.EthereumAddress,
+			EthereumAddresses: c.EthereumAddresses,
+			MulticallAddress:  c.MulticallAddress,
+		}
+		if err := validGenesis(ch.Genesis); err != nil {
+			return nil, fmt.Errorf("ethereum_genesis_hash %w", err)
+		}
+		return []Chain{ch}, nil
+	}
+
+	chains := make([]Chain, 0, len(c.Chains))
+	defined := map[string]bool{}
+	for _, ch := range c.Chains {
+		if ch.ID == 0 {
+			return nil, fmt.Errorf("chain id must be set")
+		}
+		if ch.EthereumAddress == "" && len(ch.EthereumAddresses) == 0 {
+			return nil, fmt.Errorf("chain %d ethereum_address or ethereum_addresses must be set", ch.ID)
+		}
+		if err := validGenesis(ch.Genesis); err != nil {
+			return nil, fmt.Errorf("chain %d genesis %w", ch.ID, err)
+		}
+
+		id := fmt.Sprint(ch.ID)
+		if defined[id] || (ch.Name != "" && defined[strings.ToLower(ch.Name)]) {
+			return nil, fmt.Errorf("chain %d is defined more than once", ch.ID)
+		}
+		defined[id] = true
+		if ch.Name != "" {
+			defined[strings.ToLower(ch.Name)] = true
+		}
+
+		if ch.MulticallAddress == "" {
+			ch.MulticallAddress = c.MulticallAddress
+		}
+		chains = append(chains, ch)
+	}
+	return chains, nil
+}
+
+func validGenesis(hash string) error {
+	if hash == "" {
+		return nil
+	}
+	if b, err := hexutil.Decode(hash); err != nil || len(b) != 32 {
+		return fmt.Errorf("has to be 32 bytes hex hash")
+	}
+	return nil
+}
@@ -2,10 +2,16 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/kelseyhightower/envconfig"
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -26,23 +32,114 @@ type Config struct {
 
 	Address  string `json:"address" envconfig:"ADDRESS" default:"0.0.0.0"`
 	HTTPPort string `json:"http_port" envconfig:"HTTP_PORT" default:"8097"`
+	GRPCPort string `json:"grpc_port" envconfig:"GRPC_PORT" default:"8098"`
 
-	EthereumAddress        string `json:"ethereum_address" envconfig:"ETHEREUM_ADDRESS" default:"http://0.0.0.0:8545"`
-	PredefinedNetworkNames string `json:"predefined_network_named" envconfig:"PREDEFINED_NETWORK_NAMES" default:"skale:0x00c83aeCC790e8a4453e5dD3B0B4b3680501a7A7"`
+	EthereumAddress           string        `json:"ethereum_address" envconfig:"ETHEREUM_ADDRESS" default:"http://0.0.0.0:8545"`
+	EthereumAddresses         []string      `json:"ethereum_addresses" envconfig:"ETHEREUM_ADDRESSES"`
+	EthereumMaxBlockLag       uint64        `json:"ethereum_max_block_lag" envconfig:"ETHEREUM_MAX_BLOCK_LAG" default:"5"`
+	EthereumNodeCheckInterval time.Duration `json:"ethereum_node_check_interval" envconfig:"ETHEREUM_NODE_CHECK_INTERVAL" default:"10s"`
+	EthereumProbeInterval     time.Duration `json:"ethereum_probe_interval" envconfig:"ETHEREUM_PROBE_INTERVAL" default:"5m"`
+	MulticallAddress          string        `json:"multicall_address" envconfig:"MULTICALL_ADDRESS" default:"0xcA11bde05977b3631167028862bE2a173976CA11"`
+	PredefinedNetworkNames    string        `json:"predefined_network_named" envconfig:"PREDEFINED_NETWORK_NAMES" default:"skale:0x00c83aeCC790e8a4453e5dD3B0B4b3680501a7A7"`
+
+	// EthereumChainID and EthereumGenesisHash are verified on the nodes when set
+	EthereumChainID     uint64 `json:"ethereum_chain_id" envconfig:"ETHEREUM_CHAIN_ID"`
+	EthereumGenesisHash string `json:"ethereum_genesis_hash" envconfig:"ETHEREUM_GENESIS_HASH"`
+
+	// EthereumBatchMaxSize enables collecting concurrent eth_calls into JSON-RPC batches of up to this size
+	EthereumBatchMaxSize int           `json:"ethereum_batch_max_size" envconfig:"ETHEREUM_BATCH_MAX_SIZE" default:"0"`
+	EthereumBatchLinger  time.Duration `json:"ethereum_batch_linger" envconfig:"ETHEREUM_BATCH_LINGER" default:"5ms"`
+
+	// EthereumCallTimeout bounds every contract call including its retries
+	EthereumCallTimeout time.Duration `json:"ethereum_call_timeout" envconfig:"ETHEREUM_CALL_TIMEOUT" default:"30s"`
+	// Retries of transient node errors, attempts include the first one
+	EthereumRetryAttempts   int           `json:"ethereum_retry_attempts" envconfig:"ETHEREUM_RETRY_ATTEMPTS" default:"3"`
+	EthereumRetryMinBackoff time.Duration `json:"ethereum_retry_min_backoff" envconfig:"ETHEREUM_RETRY_MIN_BACKOFF" default:"100ms"`
+	EthereumRetryMaxBackoff time.Duration `json:"ethereum_retry_max_backoff" envconfig:"ETHEREUM_RETRY_MAX_BACKOFF" default:"2s"`
+	EthereumRetryJitter     float64       `json:"ethereum_retry_jitter" envconfig:"ETHEREUM_RETRY_JITTER" default:"0.5"`
+	EthereumAttemptTimeout  time.Duration `json:"ethereum_attempt_timeout" envconfig:"ETHEREUM_ATTEMPT_TIMEOUT" default:"10s"`
+	// Node circuit breaker opens after EthereumBreakerFailures consecutive failures
+	EthereumBreakerFailures uint64        `json:"ethereum_breaker_failures" envconfig:"ETHEREUM_BREAKER_FAILURES" default:"3"`
+	EthereumBreakerCooldown time.Duration `json:"ethereum_breaker_cooldown" envconfig:"ETHEREUM_BREAKER_COOLDOWN" default:"30s"`
+
+	// EthereumRateLimit is the limit of requests per second to every node, zero disables it. Calls over the limit
+	// wait up to EthereumRateMaxWait and are rejected as upstream quota exhausted when they would wait longer.
+	EthereumRateLimit   float64       `json:"ethereum_rate_limit" envconfig:"ETHEREUM_RATE_LIMIT" default:"0"`
+	EthereumRateBurst   int           `json:"ethereum_rate_burst" envconfig:"ETHEREUM_RATE_BURST" default:"10"`
+	EthereumRateMaxWait time.Duration `json:"ethereum_rate_max_wait" envconfig:"ETHEREUM_RATE_MAX_WAIT" default:"1s"`
+	// RateLimits are limits of particular nodes, available only in config file
+	RateLimits []RateLimit `json:"rate_limits" ignored:"true"`
+
+	NativeNetworkNames []string `json:"native_network_names" envconfig:"NATIVE_NETWORK_NAMES" default:"ethereum"`
+	// Networks are structured network definitions, available only in config file
+	Networks []Network `json:"networks" ignored:"true"`
+	// Chains are the chains served by the worker, available only in config file.
+	// Top level ethereum addresses are used as the only chain when not set.
+	Chains []Chain `json:"chains" ignored:"true"`
+
+	BatchConcurrency int `json:"batch_concurrency" envconfig:"BATCH_CONCURRENCY" default:"10"`
+	ResultCacheSize  int `json:"result_cache_size" envconfig:"RESULT_CACHE_SIZE" default:"10000"`
+
+	// AdminAPIEnabled enables network registry administration endpoints
+	AdminAPIEnabled bool `json:"admin_api_enabled" envconfig:"ADMIN_API_ENABLED" default:"false"`
+	// NetworksFile is the path network registry is persisted to, empty disables persisting.
+	// Once written it holds the whole registry and configured networks are not loaded.
+	NetworksFile string `json:"networks_file" envconfig:"NETWORKS_FILE"`
+
+	// StorePath is the path of on-disk cache file, empty disables it
+	StorePath string `json:"store_path" envconfig:"STORE_PATH"`
+	// Stored results are evicted every StoreCompactInterval when there are more than StoreMaxResults
+	// or they are older than StoreResultTTL, zero values do not limit them
+	StoreMaxResults      int           `json:"store_max_results" envconfig:"STORE_MAX_RESULTS" default:"1000000"`
+	StoreResultTTL       time.Duration `json:"store_result_ttl" envconfig:"STORE_RESULT_TTL" default:"0"`
+	StoreCompactInterval time.Duration `json:"store_compact_interval" envconfig:"STORE_COMPACT_INTERVAL" default:"1m"`
+
+	// AuthEnabled requires api key on API endpoints, AuthHealth and AuthMetrics on health and metrics endpoints
+	AuthEnabled bool `json:"auth_enabled" envconfig:"AUTH_ENABLED" default:"false"`
+	AuthHealth  bool `json:"auth_health" envconfig:"AUTH_HEALTH" default:"false"`
+	AuthMetrics bool `json:"auth_metrics" envconfig:"AUTH_METRICS" default:"false"`
+	// AuthAPIKeys are api keys given as id:key or id:key:secret, keys with secret have to sign requests
+	AuthAPIKeys []string `json:"auth_api_keys" envconfig:"AUTH_API_KEYS"`
+	// AuthRateLimit is the default limit of requests per second of every key, zero disables it
+	AuthRateLimit float64       `json:"auth_rate_limit" envconfig:"AUTH_RATE_LIMIT" default:"0"`
+	AuthRateBurst int           `json:"auth_rate_burst" envconfig:"AUTH_RATE_BURST" default:"10"`
+	AuthMaxSkew   time.Duration `json:"auth_max_skew" envconfig:"AUTH_MAX_SKEW" default:"5m"`
+	// APIKeys are api keys with scopes and own rate limits, available only in config file
+	APIKeys []APIKey `json:"api_keys" ignored:"true"`
 
 	// Rollbar
 	RollbarAccessToken string `json:"rollbar_access_token" envconfig:"ROLLBAR_ACCESS_TOKEN"`
 	RollbarServerRoot  string `json:"rollbar_server_root" envconfig:"ROLLBAR_SERVER_ROOT" default:"github.com/figment-networks/account-service"`
 
 	HealthCheckInterval time.Duration `json:"health_check_interval" envconfig:"HEALTH_CHECK_INTERVAL" default:"10s"`
+	// Node readiness thresholds, zero values disable the check
+	HealthMaxHeadAge   time.Duration `json:"health_max_head_age" envconfig:"HEALTH_MAX_HEAD_AGE" default:"1m"`
+	HealthMinPeers     uint64        `json:"health_min_peers" envconfig:"HEALTH_MIN_PEERS" default:"0"`
+	HealthAllowSyncing bool          `json:"health_allow_syncing" envconfig:"HEALTH_ALLOW_SYNCING" default:"false"`
 }
 
-// FromFile reads the config from a file
+// FromFile reads the config from a JSON file, or YAML file when it has .yaml or .yml extension.
+// Fields missing in the file have the same defaults as environment variables.
 func FromFile(path string, config *Config) error {
 	data, err := ioutil.ReadFile(path)
 	if err != nil {
 		return err
 	}
+	if err = setDefaults(config); err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		// YAML is converted to JSON, so both formats share json field tags
+		var v interface{}
+		if err = yaml.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		if data, err = json.Marshal(v); err != nil {
+			return err
+		}
+	}
 	return json.Unmarshal(data, config)
 }
 
@@ -50,3 +147,72 @@ func FromFile(path string, config *Config) error {
 func FromEnv(config *Config) error {
 	return envconfig.Process("", config)
 }
+
+// setDefaults sets fields to the values of their default tags, as envconfig does for missing variables
+func setDefaults(config *Config) error {
+	v := reflect.ValueOf(config).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		def, ok := sf.Tag.Lookup("default")
+		if !ok {
+			continue
+		}
+		if err := setValue(v.Field(i), def); err != nil {
+			return fmt.Errorf("invalid default of %s: %w", sf.Name, err)
+		}
+	}
+	return nil
+}
+
+func setValue(f reflect.Value, s string) error {
+	if f.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		f.SetInt(int64(d))
+		return nil
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 0, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 0, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, f.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.SetFloat(n)
+	case reflect.Slice:
+		// slices are comma separated as in envconfig
+		parts := strings.Split(s, ",")
+		sl := reflect.MakeSlice(f.Type(), len(parts), len(parts))
+		for i, p := range parts {
+			if err := setValue(sl.Index(i), p); err != nil {
+				return err
+			}
+		}
+		f.Set(sl)
+	default:
+		return fmt.Errorf("unsupported type %s", f.Type())
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFromFile(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+	}{
+		{name: "json", file: "config.json", data: `{"ethereum_address": "http://node:8545", "http_port": "9000", "health_max_head_age": 0}`},
+		{name: "yaml", file: "config.yaml", data: "ethereum_address: http://node:8545\nhttp_port: \"9000\"\nhealth_max_head_age: 0\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := ioutil.WriteFile(path, []byte(tt.data), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := &Config{}
+			if err := FromFile(path, cfg); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// values set in the file
+			if cfg.EthereumAddress != "http://node:8545" || cfg.HTTPPort != "9000" || cfg.HealthMaxHeadAge != 0 {
+				t.Errorf("file values = %q %q %v", cfg.EthereumAddress, cfg.HTTPPort, cfg.HealthMaxHeadAge)
+			}
+			// defaults of the missing ones are the same as of environment variables
+			if cfg.MulticallAddress != "0xcA11bde05977b3631167028862bE2a173976CA11" {
+				t.Errorf("MulticallAddress = %q", cfg.MulticallAddress)
+			}
+			if cfg.GRPCPort != "8098" {
+				t.Errorf("GRPCPort = %q", cfg.GRPCPort)
+			}
+			if !reflect.DeepEqual(cfg.NativeNetworkNames, []string{"ethereum"}) {
+				t.Errorf("NativeNetworkNames = %v", cfg.NativeNetworkNames)
+			}
+			if cfg.ResultCacheSize != 10000 {
+				t.Errorf("ResultCacheSize = %d", cfg.ResultCacheSize)
+			}
+			if cfg.EthereumCallTimeout != 30*time.Second || cfg.EthereumRetryJitter != 0.5 || cfg.EthereumBreakerFailures != 3 {
+				t.Errorf("defaults = %v %v %v", cfg.EthereumCallTimeout, cfg.EthereumRetryJitter, cfg.EthereumBreakerFailures)
+			}
+
+			env := &Config{}
+			clearEnv(t)
+			if err := FromEnv(env); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			env.EthereumAddress, env.HTTPPort, env.HealthMaxHeadAge = cfg.EthereumAddress, cfg.HTTPPort, cfg.HealthMaxHeadAge
+			if !reflect.DeepEqual(cfg, env) {
+				t.Errorf("file config = %+v, want the env defaults %+v", cfg, env)
+			}
+		})
+	}
+}
+
+// clearEnv clears environment variables until the test ends
+func clearEnv(t *testing.T) {
+	environ := os.Environ()
+	os.Clearenv()
+	t.Cleanup(func() {
+		for _, kv := range environ {
+			if i := strings.Index(kv, "="); i > 0 {
+				os.Setenv(kv[:i], kv[i+1:])
+			}
+		}
+	})
+}
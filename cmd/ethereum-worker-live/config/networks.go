@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Token standards of configured networks
+const (
+	StandardERC20  = "erc20"
+	StandardNative = "native"
+)
+
+// Network is a structured network entry of the config file
+type Network struct {
+	ID       string   `json:"id"`
+	Aliases  []string `json:"aliases"`
+	Contract string   `json:"contract"`
+	// Standard is either erc20 (default) or native
+	Standard string `json:"standard"`
+	Chain    string `json:"chain"`
+	// Overrides replace details read from the contract
+	Overrides *NetworkOverrides `json:"overrides"`
+	// StartBlock is the block contract was deployed at
+	StartBlock uint64 `json:"start_block"`
+}
+
+// NetworkOverrides are token details set instead of the ones read from the contract
+type NetworkOverrides struct {
+	Name     string  `json:"name"`
+	Symbol   string  `json:"symbol"`
+	Decimals *uint64 `json:"decimals"`
+}
+
+// NetworkList returns structured networks followed by the ones given in legacy
+// PredefinedNetworkNames format, skipping legacy ones already defined in structured form.
+func (c *Config) NetworkList() ([]Network, error) {
+	networks := make([]Network, 0, len(c.Networks))
+	defined := map[string]bool{}
+	for _, n := range c.Networks {
+		if n.ID == "" {
+			return nil, fmt.Errorf("network id must be set")
+		}
+		switch n.Standard {
+		case "":
+			n.Standard = StandardERC20
+		case StandardERC20, StandardNative:
+		default:
+			return nil, fmt.Errorf("network %s has unsupported standard %q", n.ID, n.Standard)
+		}
+		if n.Standard == StandardERC20 && n.Contract == "" {
+			return nil, fmt.Errorf("network %s contract must be set", n.ID)
+		}
+		networks = append(networks, n)
+		defined[strings.ToLower(n.ID)] = true
+	}
+
+	if c.PredefinedNetworkNames == "" {
+		return networks, nil
+	}
+	for _, pair := range strings.Split(c.PredefinedNetworkNames, ";") {
+		network := strings.Split(pair, ":")
+		if len(network) != 2 {
+			return nil, fmt.Errorf("PredefinedNetworkNames has to be in name:address;name:address;name:address format")
+		}
+		if defined[strings.ToLower(network[0])] {
+			continue
+		}
+		networks = append(networks, Network{ID: network[0], Contract: network[1], Standard: StandardERC20})
+	}
+	return networks, nil
+}
@@ -0,0 +1,22 @@
+package config
+
+// RateLimit is a rate limit of calls to the node of given url, available only in config file
+type RateLimit struct {
+	URL string `json:"url"`
+	// RPS is the number of requests per second, zero disables the limit
+	RPS   float64 `json:"rps"`
+	Burst int     `json:"burst"`
+}
+
+// RateLimitFor returns rate limit of the node url, top level limit applies to nodes not listed in rate_limits
+func (c *Config) RateLimitFor(url string) RateLimit {
+	for _, rl := range c.RateLimits {
+		if rl.URL == url {
+			if rl.Burst == 0 {
+				rl.Burst = c.EthereumRateBurst
+			}
+			return rl
+		}
+	}
+	return RateLimit{URL: url, RPS: c.EthereumRateLimit, Burst: c.EthereumRateBurst}
+}
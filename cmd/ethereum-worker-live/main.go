@@ -4,19 +4,31 @@ import (
 	"context"
 	"embed"
 	"encoding/json"
+	"errors"
 	"flag"
 	"log"
+	"net"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/figment-networks/ethereum-worker/api/conn"
 	"github.com/figment-networks/ethereum-worker/api/conn/eth"
+	"github.com/figment-networks/ethereum-worker/api/conn/multi"
+	"github.com/figment-networks/ethereum-worker/api/erc1155"
 	"github.com/figment-networks/ethereum-worker/api/erc20"
+	"github.com/figment-networks/ethereum-worker/api/erc721"
 	"github.com/figment-networks/ethereum-worker/client"
 	"github.com/figment-networks/ethereum-worker/cmd/ethereum-worker-live/config"
 	"github.com/figment-networks/ethereum-worker/cmd/ethereum-worker-live/logger"
+	"github.com/figment-networks/ethereum-worker/health/nodehealth"
+	"github.com/figment-networks/ethereum-worker/store"
+	"github.com/figment-networks/ethereum-worker/store/bolt"
+	"github.com/figment-networks/ethereum-worker/structures"
 
+	tgrpc "github.com/figment-networks/ethereum-worker/transport/grpc"
 	thttp "github.com/figment-networks/ethereum-worker/transport/http"
 
 	"github.com/figment-networks/indexing-engine/health"
@@ -24,6 +36,7 @@ import (
 	"github.com/figment-networks/indexing-engine/metrics/prometheusmetrics"
 
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 )
 
 //go:embed abis/*
@@ -49,6 +62,10 @@ func main() {
 	if err != nil {
 		log.Fatalf("error initializing config [ERR: %v]", err.Error())
 	}
+	// network administration is never served unauthenticated
+	if cfg.AdminAPIEnabled && !cfg.AuthEnabled {
+		log.Fatal("admin API requires authentication, ADMIN_API_ENABLED is set without AUTH_ENABLED")
+	}
 
 	if cfg.RollbarServerRoot == "" {
 		cfg.RollbarServerRoot = "github.com/figment-networks/ethereum-worker"
@@ -81,13 +98,6 @@ func main() {
 		logger.Error(err)
 	}
 
-	tr := eth.NewEthTransport(cfg.EthereumAddress)
-	if err := tr.Dial(ctx); err != nil {
-		logger.Fatal("Error dialing ethereum", zap.String("ethereum_address", cfg.EthereumAddress), zap.Error(err))
-		return
-	}
-	defer tr.Close(ctx)
-
 	file, err := abis.ReadFile("abis/erc20abi.json")
 	if err != nil {
 		logger.Fatal("Error opening  erc20abi.json", zap.Error(err))
@@ -98,35 +108,372 @@ func main() {
 		logger.Fatal("Error opening  erc20abi.json", zap.Error(err))
 		return
 	}
-	cl := client.NewClient(logger.GetLogger(), &erc20.ERC20Caller{}, tr, *erc20abi)
+
+	file, err = abis.ReadFile("abis/multicall3abi.json")
+	if err != nil {
+		logger.Fatal("Error opening  multicall3abi.json", zap.Error(err))
+		return
+	}
+	multicallabi := &abi.ABI{}
+	if err = json.Unmarshal(file, multicallabi); err != nil {
+		logger.Fatal("Error opening  multicall3abi.json", zap.Error(err))
+		return
+	}
+
+	cl := client.NewClient(logger.GetLogger(), &erc20.ERC20Caller{Timeout: cfg.EthereumCallTimeout}, *erc20abi)
 	client.Init()
+	cl.SetBatchConcurrency(cfg.BatchConcurrency)
+	cl.SetCallTimeout(cfg.EthereumCallTimeout)
+
+	chains, err := cfg.ChainList()
+	if err != nil {
+		logger.Fatal("Error reading chains config", zap.Error(err))
+		return
+	}
+	for _, c := range chains {
+		tr := newTransport(cfg, c)
+		if err := tr.Dial(ctx); err != nil {
+			logger.Fatal("Error dialing ethereum", zap.Uint64("chain", c.ID), zap.String("ethereum_address", c.EthereumAddress), zap.Strings("ethereum_addresses", c.EthereumAddresses), zap.Error(err))
+			return
+		}
+		defer tr.Close(ctx)
+
+		id := tr.Identity()
+		logger.Info("Chain verified", zap.Uint64("chain_id", id.ChainID), zap.String("genesis", id.Genesis.Hex()))
+		if c.ID == 0 {
+			c.ID = id.ChainID
+		}
+
+		if h := tr.History(); h.Probed.IsZero() {
+			logger.Info("Historical state availability unknown, node probe failed", zap.Uint64("chain", c.ID))
+		} else {
+			logger.Info("Historical state availability", zap.Uint64("chain", c.ID), zap.Bool("archive", h.Archive), zap.Uint64("oldest_block", h.Oldest))
+		}
+
+		ch := cl.AddChain(c.ID, c.Name, tr)
+		if c.MulticallAddress != "" {
+			mc := erc20.NewMulticall(*erc20abi)
+			mc.Timeout = cfg.EthereumCallTimeout
+			ch.SetMulticall(mc, c.MulticallAddress, *multicallabi)
+		}
+		if c.Native != nil {
+			ch.SetNativeDetails(structures.Details{Name: c.Native.Name, Symbol: c.Native.Symbol, Decimals: c.Native.Decimals})
+		}
+	}
+
+	networks, err := cfg.NetworkList()
+	if err != nil {
+		logger.Fatal("Error reading networks config", zap.Error(err))
+		return
+	}
+	nativeNames := cfg.NativeNetworkNames
+	for _, n := range networks {
+		if n.Standard == config.StandardNative && n.Chain == "" {
+			nativeNames = append(append(nativeNames, n.ID), n.Aliases...)
+		}
+	}
+	cl.SetNativeNetworks(nativeNames)
+	for _, n := range networks {
+		if n.Standard == config.StandardNative && n.Chain != "" {
+			for _, name := range append([]string{n.ID}, n.Aliases...) {
+				cl.SetNativeNetwork(name, n.Chain)
+			}
+		}
+	}
+	if cfg.ResultCacheSize > 0 {
+		cl.SetResultCache(client.NewResultCache(cfg.ResultCacheSize))
+	}
+	if cfg.StorePath != "" {
+		st, err := bolt.NewBoltStore(cfg.StorePath, bolt.Limit{Bucket: store.BucketResults, MaxEntries: cfg.StoreMaxResults, TTL: cfg.StoreResultTTL})
+		if err != nil {
+			logger.Fatal("Error opening store", zap.String("store_path", cfg.StorePath), zap.Error(err))
+			return
+		}
+		defer st.Close()
+		cl.SetStore(st)
+		go compactStore(ctx, logger.GetLogger(), st, cfg.StoreCompactInterval)
+	}
+
+	file, err = abis.ReadFile("abis/erc721abi.json")
+	if err != nil {
+		logger.Fatal("Error opening  erc721abi.json", zap.Error(err))
+		return
+	}
+	erc721abi := &abi.ABI{}
+	if err = json.Unmarshal(file, erc721abi); err != nil {
+		logger.Fatal("Error opening  erc721abi.json", zap.Error(err))
+		return
+	}
+	cl.SetERC721(&erc721.ERC721Caller{Timeout: cfg.EthereumCallTimeout}, *erc721abi)
+
+	file, err = abis.ReadFile("abis/erc1155abi.json")
+	if err != nil {
+		logger.Fatal("Error opening  erc1155abi.json", zap.Error(err))
+		return
+	}
+	erc1155abi := &abi.ABI{}
+	if err = json.Unmarshal(file, erc1155abi); err != nil {
+		logger.Fatal("Error opening  erc1155abi.json", zap.Error(err))
+		return
+	}
+	cl.SetERC1155(&erc1155.ERC1155Caller{Timeout: cfg.EthereumCallTimeout}, *erc1155abi)
 
-	nNames := strings.Split(cfg.PredefinedNetworkNames, ";")
-	for _, pair := range nNames {
-		if !strings.ContainsAny(pair, ":") {
-			logger.Fatal("PredefinedNetworkNames has to be in name:address;name:address;name:address format")
+	var networksLoaded bool
+	if cfg.NetworksFile != "" {
+		cl.SetNetworksFile(cfg.NetworksFile)
+		if networksLoaded, err = cl.LoadNetworksFile(ctx); err != nil {
+			logger.Fatal("Error loading networks file", zap.String("networks_file", cfg.NetworksFile), zap.Error(err))
 			return
 		}
-		network := strings.Split(pair, ":")
-		logger.Info("Loading network: ", zap.String("name", network[0]), zap.String("address", network[1]), zap.String("node_address", cfg.EthereumAddress))
-		if err = cl.LoadNetworkNames(ctx, network[0], network[1]); err != nil {
-			logger.Fatal("Error loading network ", zap.Strings("config ", network), zap.Error(err))
+	}
+
+	if networksLoaded {
+		reportConfiguredNetworks(logger.GetLogger(), cfg.NetworksFile, networks, cl.ListNetworks())
+	} else {
+		for _, n := range networks {
+			if n.Standard != config.StandardERC20 {
+				continue
+			}
+			logger.Info("Loading network: ", zap.String("name", n.ID), zap.String("address", n.Contract), zap.String("chain", n.Chain))
+			err = cl.LoadNetwork(ctx, structures.Network{
+				Name:       n.ID,
+				Aliases:    n.Aliases,
+				Address:    n.Contract,
+				Chain:      n.Chain,
+				StartBlock: n.StartBlock,
+				Overrides:  overrides(n.Overrides),
+			})
+			if err != nil {
+				logger.Fatal("Error loading network ", zap.String("name", n.ID), zap.Error(err))
+			}
+		}
+	}
+
+	auth, err := newAuthenticator(cfg)
+	if err != nil {
+		logger.Fatal("Error reading api keys", zap.Error(err))
+		return
+	}
+	// protect requires api key with the scope when authentication of the endpoint is enabled
+	protect := func(enabled bool, scope string, h http.Handler) http.Handler {
+		if !enabled {
+			return h
 		}
+		return auth.Protect(scope, h)
 	}
 
 	connector := thttp.NewConnector(cl, logger.GetLogger())
 	mux := http.NewServeMux()
 
-	connector.AttachToHandler(mux)
-	mux.Handle("/metrics", metrics.Handler())
+	apiMux := http.NewServeMux()
+	connector.AttachToHandler(apiMux)
+	mux.Handle("/", protect(cfg.AuthEnabled, thttp.ScopeAPI, apiMux))
+	if cfg.AdminAPIEnabled {
+		adminMux := http.NewServeMux()
+		connector.AttachAdminToHandler(adminMux, cl)
+		mux.Handle("/admin/", auth.Protect(thttp.ScopeAdmin, adminMux))
+	}
+	mux.Handle("/metrics", protect(cfg.AuthMetrics, thttp.ScopeMetrics, metrics.Handler()))
 
 	monitor := &health.Monitor{}
+	th := nodehealth.Thresholds{MaxHeadAge: cfg.HealthMaxHeadAge, MinPeers: cfg.HealthMinPeers, AllowSyncing: cfg.HealthAllowSyncing}
+	for _, ch := range cl.Chains() {
+		monitor.AddProber(ctx, nodehealth.NewNodeMonitor(ch.String(), ch.Transport(), th, logger.GetLogger()))
+	}
 	go monitor.RunChecks(ctx, cfg.HealthCheckInterval)
-	monitor.AttachHttp(mux)
+	healthMux := http.NewServeMux()
+	monitor.AttachHttp(healthMux)
+	for _, path := range []string{"/health", "/liveness", "/readiness"} {
+		mux.Handle(path, protect(cfg.AuthHealth, thttp.ScopeHealth, healthMux))
+	}
+
+	if cfg.GRPCPort != "" {
+		var opts []grpc.ServerOption
+		if cfg.AuthEnabled {
+			opts = append(opts, grpc.UnaryInterceptor(tgrpc.AuthInterceptor(auth, thttp.ScopeAPI, logger.GetLogger())))
+		}
+		grpcServer := grpc.NewServer(opts...)
+		tgrpc.NewConnector(cl, logger.GetLogger()).Register(grpcServer)
+		go handleGRPC(logger.GetLogger(), *cfg, grpcServer)
+		defer grpcServer.GracefulStop()
+	}
 
 	handleHTTP(logger.GetLogger(), *cfg, mux)
 }
 
+// newTransport returns transport balancing multiple nodes when chain has more than one
+func newTransport(cfg *config.Config, c config.Chain) conn.EthereumTransport {
+	expected := conn.Identity{ChainID: c.ID}
+	if c.Genesis != "" {
+		expected.Genesis = common.HexToHash(c.Genesis)
+	}
+	retry := conn.DefaultRetryPolicy
+	if cfg.EthereumRetryAttempts > 0 {
+		retry.MaxAttempts = cfg.EthereumRetryAttempts
+	}
+	if cfg.EthereumRetryMinBackoff > 0 {
+		retry.MinBackoff = cfg.EthereumRetryMinBackoff
+	}
+	if cfg.EthereumRetryMaxBackoff > 0 {
+		retry.MaxBackoff = cfg.EthereumRetryMaxBackoff
+	}
+	if cfg.EthereumRetryJitter > 0 {
+		retry.Jitter = cfg.EthereumRetryJitter
+	}
+	if cfg.EthereumAttemptTimeout > 0 {
+		retry.AttemptTimeout = cfg.EthereumAttemptTimeout
+	}
+
+	if len(c.EthereumAddresses) > 0 {
+		mt := multi.NewMultiTransport(logger.GetLogger(), c.EthereumAddresses)
+		mt.ExpectedChain = expected
+		mt.Retry = retry
+		mt.Limiters = map[string]*conn.Limiter{}
+		for _, u := range c.EthereumAddresses {
+			if l := limiter(cfg, u); l != nil {
+				mt.Limiters[u] = l
+			}
+		}
+		if cfg.EthereumBreakerFailures > 0 {
+			mt.MaxFailures = cfg.EthereumBreakerFailures
+		}
+		if cfg.EthereumBreakerCooldown > 0 {
+			mt.Cooldown = cfg.EthereumBreakerCooldown
+		}
+		if cfg.EthereumMaxBlockLag > 0 {
+			mt.MaxLag = cfg.EthereumMaxBlockLag
+		}
+		if cfg.EthereumNodeCheckInterval > 0 {
+			mt.CheckInterval = cfg.EthereumNodeCheckInterval
+		}
+		if cfg.EthereumProbeInterval > 0 {
+			mt.ProbeInterval = cfg.EthereumProbeInterval
+		}
+		mt.BatchMaxSize = cfg.EthereumBatchMaxSize
+		if cfg.EthereumBatchLinger > 0 {
+			mt.BatchLinger = cfg.EthereumBatchLinger
+		}
+		return mt
+	}
+
+	et := eth.NewEthTransport(logger.GetLogger(), c.EthereumAddress)
+	et.ExpectedChain = expected
+	et.Retry = retry
+	et.Limiter = limiter(cfg, c.EthereumAddress)
+	if cfg.EthereumBreakerFailures > 0 {
+		et.MaxFailures = cfg.EthereumBreakerFailures
+	}
+	if cfg.EthereumBreakerCooldown > 0 {
+		et.Cooldown = cfg.EthereumBreakerCooldown
+	}
+	if cfg.EthereumProbeInterval > 0 {
+		et.ProbeInterval = cfg.EthereumProbeInterval
+	}
+	et.BatchMaxSize = cfg.EthereumBatchMaxSize
+	if cfg.EthereumBatchLinger > 0 {
+		et.BatchLinger = cfg.EthereumBatchLinger
+	}
+	return et
+}
+
+// reportConfiguredNetworks warns about configured ERC20 networks the networks file does not match. Once the admin API
+// writes the file, it holds the whole registry and configured networks are not loaded anymore, so networks removed
+// at runtime stay removed. Configured networks are loaded again only when the file is deleted.
+func reportConfiguredNetworks(l *zap.Logger, networksFile string, configured []config.Network, persisted []structures.Network) {
+	byName := map[string]structures.Network{}
+	for _, n := range persisted {
+		byName[strings.ToLower(n.Name)] = n
+	}
+	for _, n := range configured {
+		if n.Standard != config.StandardERC20 {
+			continue
+		}
+		p, ok := byName[strings.ToLower(n.ID)]
+		switch {
+		case !ok:
+			l.Warn("Configured network is not in networks file, it is not loaded", zap.String("name", n.ID), zap.String("networks_file", networksFile))
+		case !strings.EqualFold(p.Address, n.Contract):
+			l.Warn("Configured network differs from networks file, which takes precedence", zap.String("name", n.ID), zap.String("address", n.Contract), zap.String("networks_file", networksFile), zap.String("persisted_address", p.Address))
+		}
+	}
+}
+
+// newAuthenticator returns authenticator of configured api keys, nil when authentication is not enabled
+func newAuthenticator(cfg *config.Config) (*thttp.Authenticator, error) {
+	if !cfg.AuthEnabled && !cfg.AuthHealth && !cfg.AuthMetrics {
+		return nil, nil
+	}
+	keys, err := cfg.APIKeyList()
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("authentication is enabled, but no api keys are configured")
+	}
+
+	apiKeys := make([]thttp.APIKey, len(keys))
+	for i, k := range keys {
+		apiKeys[i] = thttp.APIKey{ID: k.ID, Key: k.Key, Secret: k.Secret, RPS: k.RPS, Burst: k.Burst, Scopes: k.Scopes}
+	}
+	auth, err := thttp.NewAuthenticator(apiKeys, logger.GetLogger())
+	if err != nil {
+		return nil, err
+	}
+	if cfg.AuthMaxSkew > 0 {
+		auth.MaxSkew = cfg.AuthMaxSkew
+	}
+	return auth, nil
+}
+
+// limiters are shared by node url, as the quota belongs to provider's api key that is usually a part of it
+var limiters = map[string]*conn.Limiter{}
+
+// limiter returns rate limiter of the node, nil when it is not limited
+func limiter(cfg *config.Config, url string) *conn.Limiter {
+	if l, ok := limiters[url]; ok {
+		return l
+	}
+	rl := cfg.RateLimitFor(url)
+	if rl.RPS <= 0 {
+		return nil
+	}
+	l := conn.NewLimiter(url, rl.RPS, rl.Burst, cfg.EthereumRateMaxWait)
+	limiters[url] = l
+	return l
+}
+
+// compactStore evicts stored results over their limits every interval
+func compactStore(ctx context.Context, l *zap.Logger, st *bolt.BoltStore, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	tckr := time.NewTicker(interval)
+	defer tckr.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tckr.C:
+			removed, err := st.Compact()
+			if err != nil {
+				l.Warn("Error compacting store", zap.Error(err))
+				continue
+			}
+			if removed > 0 {
+				l.Debug("Store compacted", zap.Int("removed", removed))
+			}
+		}
+	}
+}
+
+func overrides(o *config.NetworkOverrides) *structures.DetailsOverrides {
+	if o == nil {
+		return nil
+	}
+	return &structures.DetailsOverrides{Name: o.Name, Symbol: o.Symbol, Decimals: o.Decimals}
+}
+
 func getConfig(path string) (cfg *config.Config, err error) {
 	cfg = &config.Config{}
 	if path != "" {
@@ -135,7 +482,7 @@ func getConfig(path string) (cfg *config.Config, err error) {
 		}
 	}
 
-	if cfg.EthereumAddress != "" {
+	if cfg.EthereumAddress != "" || len(cfg.EthereumAddresses) > 0 || len(cfg.Chains) > 0 {
 		return cfg, nil
 	}
 
@@ -156,6 +503,19 @@ func handleHTTP(l *zap.Logger, cfg config.Config, mux *http.ServeMux) {
 
 	l.Info("[HTTP] Listening on", zap.String("address", cfg.Address), zap.String("port", cfg.HTTPPort))
 	if err := s.ListenAndServe(); err != nil {
-		l.Error("[GRPC] Error while listening ", zap.String("address", cfg.Address), zap.String("port", cfg.HTTPPort), zap.Error(err))
+		l.Error("[HTTP] Error while listening ", zap.String("address", cfg.Address), zap.String("port", cfg.HTTPPort), zap.Error(err))
+	}
+}
+
+func handleGRPC(l *zap.Logger, cfg config.Config, s *grpc.Server) {
+	lis, err := net.Listen("tcp", cfg.Address+":"+cfg.GRPCPort)
+	if err != nil {
+		l.Error("[GRPC] Error while listening ", zap.String("address", cfg.Address), zap.String("port", cfg.GRPCPort), zap.Error(err))
+		return
+	}
+
+	l.Info("[GRPC] Listening on", zap.String("address", cfg.Address), zap.String("port", cfg.GRPCPort))
+	if err := s.Serve(lis); err != nil {
+		l.Error("[GRPC] Error while serving ", zap.String("address", cfg.Address), zap.String("port", cfg.GRPCPort), zap.Error(err))
 	}
 }
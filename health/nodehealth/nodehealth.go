@@ -0,0 +1,228 @@
+package nodehealth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"go.uber.org/zap"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+	"github.com/figment-networks/indexing-engine/metrics"
+)
+
+var (
+	ErrNodeUnreachable = errors.New("node is not reachable")
+	ErrNodeSyncing     = errors.New("node is syncing")
+	ErrHeadTooOld      = errors.New("node head is too old")
+	ErrNotEnoughPeers  = errors.New("node has not enough peers")
+)
+
+var (
+	headAgeMetric = metrics.MustNewGaugeWithTags(metrics.Options{
+		Namespace: "health",
+		Subsystem: "node",
+		Name:      "head_age",
+		Desc:      "Seconds since the node's head block was produced",
+		Tags:      []string{"chain"},
+	})
+
+	peersMetric = metrics.MustNewGaugeWithTags(metrics.Options{
+		Namespace: "health",
+		Subsystem: "node",
+		Name:      "peers",
+		Desc:      "Number of node's peers",
+		Tags:      []string{"chain"},
+	})
+
+	readyMetric = metrics.MustNewGaugeWithTags(metrics.Options{
+		Namespace: "health",
+		Subsystem: "node",
+		Name:      "ready",
+		Desc:      "Node readiness, 1 when all the checks pass",
+		Tags:      []string{"chain"},
+	})
+)
+
+// probeType is the type monitor renders on /readiness, indexing-engine health.Monitor lists only "db" probers,
+// so node checks are reported under db.ethereum.<chain>
+const probeType = "db"
+
+// Thresholds configure when node is considered not ready
+type Thresholds struct {
+	// MaxHeadAge is the maximum time since the head block was produced, zero disables the check
+	MaxHeadAge time.Duration
+	// MinPeers is the minimum number of node's peers, zero disables the check.
+	// Hosted nodes often do not serve net_peerCount.
+	MinPeers uint64
+	// AllowSyncing keeps the node ready while it is syncing
+	AllowSyncing bool
+}
+
+// NodeCheck is the result of the last check of a node
+type NodeCheck struct {
+	URL     string        `json:"url"`
+	On      time.Time     `json:"on"`
+	Status  string        `json:"status"`
+	Error   string        `json:"error,omitempty"`
+	Head    uint64        `json:"head"`
+	HeadAge time.Duration `json:"headAge"`
+	Syncing bool          `json:"syncing"`
+	Peers   uint64        `json:"peers,omitempty"`
+}
+
+// NodeMonitor is health.Prober checking reachability, sync status, head age and peer count of every chain's node.
+// Nodes are called directly, not through the transport's retries, rate limits and circuit breakers,
+// so checks neither consume the quota nor see a node hidden by failover. Chain is ready when any of its nodes is.
+type NodeMonitor struct {
+	name string
+	t    conn.EthereumTransport
+	th   Thresholds
+	l    *zap.Logger
+
+	headAgeM metrics.Gauge
+	peersM   metrics.Gauge
+	readyM   metrics.Gauge
+
+	ncL     sync.RWMutex
+	nc      []NodeCheck
+	checked bool
+	err     error
+}
+
+// NewNodeMonitor is NodeMonitor constructor, name is the name of monitored chain
+func NewNodeMonitor(name string, t conn.EthereumTransport, th Thresholds, l *zap.Logger) *NodeMonitor {
+	return &NodeMonitor{
+		name:     name,
+		t:        t,
+		th:       th,
+		l:        l,
+		headAgeM: headAgeMetric.WithLabels(name),
+		peersM:   peersMetric.WithLabels(name),
+		readyM:   readyMetric.WithLabels(name),
+	}
+}
+
+// Probe checks the nodes, it is run periodically by the health monitor
+func (m *NodeMonitor) Probe(ctx context.Context) error {
+	return m.check(ctx)
+}
+
+// Readiness reports the result of the last periodic check, nodes are checked only when they were not checked yet
+func (m *NodeMonitor) Readiness(ctx context.Context) (probetype, readinesstype, name string, contents interface{}, err error) {
+	m.ncL.RLock()
+	checked, nc, err := m.checked, m.nc, m.err
+	m.ncL.RUnlock()
+
+	if !checked {
+		err = m.check(ctx)
+		nc = m.Last()
+	}
+	return probeType, "ethereum", m.name, nc, err
+}
+
+func (m *NodeMonitor) Liveness(ctx context.Context) (probetype, livenesstype, name string, contents interface{}, err error) {
+	return probeType, "ethereum", m.name, nil, nil
+}
+
+// Last returns the results of the last check of every node
+func (m *NodeMonitor) Last() []NodeCheck {
+	m.ncL.RLock()
+	defer m.ncL.RUnlock()
+	return append([]NodeCheck(nil), m.nc...)
+}
+
+// check checks all the nodes concurrently. Metrics report the best node, that is the one with the most recent head.
+func (m *NodeMonitor) check(ctx context.Context) (err error) {
+	nodes := m.t.NodeConns()
+	nc := make([]NodeCheck, len(nodes))
+	errs := make([]error, len(nodes))
+
+	wg := &sync.WaitGroup{}
+	for i, n := range nodes {
+		wg.Add(1)
+		go func(i int, n conn.NodeConn) {
+			defer wg.Done()
+			nc[i], errs[i] = m.checkNode(ctx, n)
+		}(i, n)
+	}
+	wg.Wait()
+
+	// chain not ready reports the error of the most preferred node
+	err = fmt.Errorf("%w: no dialed node", ErrNodeUnreachable)
+	if len(errs) > 0 && errs[0] != nil {
+		err = errs[0]
+	}
+	var best *NodeCheck
+	for i := range nc {
+		if errs[i] != nil {
+			m.l.Warn("[Health][Node] Node is not ready", zap.String("chain", m.name), zap.String("url", nc[i].URL), zap.Error(errs[i]))
+			continue
+		}
+		if best == nil || nc[i].Head > best.Head {
+			best = &nc[i]
+		}
+	}
+
+	if best != nil {
+		err = nil
+		m.headAgeM.Set(best.HeadAge.Seconds())
+		if m.th.MinPeers > 0 {
+			m.peersM.Set(float64(best.Peers))
+		}
+		m.readyM.Set(1)
+	} else {
+		m.readyM.Set(0)
+	}
+
+	m.ncL.Lock()
+	m.nc, m.checked, m.err = nc, true, err
+	m.ncL.Unlock()
+	return err
+}
+
+func (m *NodeMonitor) checkNode(ctx context.Context, n conn.NodeConn) (nc NodeCheck, err error) {
+	tCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+	defer cancel()
+
+	nc = NodeCheck{URL: n.URL, On: time.Now(), Status: "ok"}
+	defer func() {
+		if err != nil {
+			nc.Status = "err"
+			nc.Error = err.Error()
+		}
+	}()
+
+	c := ethclient.NewClient(n.RPC)
+	head, err := c.HeaderByNumber(tCtx, nil)
+	if err != nil {
+		return nc, fmt.Errorf("%w: %s", ErrNodeUnreachable, err.Error())
+	}
+	nc.Head = head.Number.Uint64()
+	nc.HeadAge = nc.On.Sub(time.Unix(int64(head.Time), 0))
+
+	progress, err := c.SyncProgress(tCtx)
+	if err != nil {
+		return nc, fmt.Errorf("error reading sync status: %w", err)
+	}
+	nc.Syncing = progress != nil
+
+	if m.th.MinPeers > 0 {
+		if nc.Peers, err = conn.PeerCount(tCtx, n.RPC); err != nil {
+			return nc, fmt.Errorf("error reading peer count: %w", err)
+		}
+	}
+
+	switch {
+	case nc.Syncing && !m.th.AllowSyncing:
+		return nc, fmt.Errorf("%w: at %d of %d", ErrNodeSyncing, progress.CurrentBlock, progress.HighestBlock)
+	case m.th.MaxHeadAge > 0 && nc.HeadAge > m.th.MaxHeadAge:
+		return nc, fmt.Errorf("%w: block %d is %s old", ErrHeadTooOld, nc.Head, nc.HeadAge.Round(time.Second))
+	case m.th.MinPeers > 0 && nc.Peers < m.th.MinPeers:
+		return nc, fmt.Errorf("%w: %d, minimum is %d", ErrNotEnoughPeers, nc.Peers, m.th.MinPeers)
+	}
+	return nc, nil
+}
@@ -0,0 +1,221 @@
+package nodehealth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.uber.org/zap"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+	"github.com/figment-networks/indexing-engine/health"
+)
+
+// nodeService serves head, sync status and peer count of a node
+type nodeService struct {
+	down    bool
+	head    uint64
+	headAge time.Duration
+	syncing bool
+	peers   uint64
+}
+
+func (ns *nodeService) GetBlockByNumber(number string, full bool) (*types.Header, error) {
+	if ns.down {
+		return nil, errors.New("connection refused")
+	}
+	return &types.Header{
+		Number:     new(big.Int).SetUint64(ns.head),
+		Time:       uint64(time.Now().Add(-ns.headAge).Unix()),
+		Difficulty: big.NewInt(0),
+		Extra:      []byte{},
+	}, nil
+}
+
+func (ns *nodeService) Syncing() (interface{}, error) {
+	if !ns.syncing {
+		return false, nil
+	}
+	return map[string]hexutil.Uint64{"startingBlock": 0, "currentBlock": hexutil.Uint64(ns.head), "highestBlock": hexutil.Uint64(ns.head + 100)}, nil
+}
+
+func (ns *nodeService) PeerCount() hexutil.Uint64 {
+	return hexutil.Uint64(ns.peers)
+}
+
+// nodesTransport gives direct connections of the nodes, any other call panics
+type nodesTransport struct {
+	conn.EthereumTransport
+	ncs []conn.NodeConn
+}
+
+func (nt *nodesTransport) NodeConns() []conn.NodeConn { return nt.ncs }
+
+func newNodesTransport(t *testing.T, nodes ...*nodeService) *nodesTransport {
+	nt := &nodesTransport{}
+	for _, ns := range nodes {
+		srv := rpc.NewServer()
+		if err := srv.RegisterName("eth", ns); err != nil {
+			t.Fatal(err)
+		}
+		if err := srv.RegisterName("net", ns); err != nil {
+			t.Fatal(err)
+		}
+		c := rpc.DialInProc(srv)
+		t.Cleanup(func() {
+			c.Close()
+			srv.Stop()
+		})
+		nt.ncs = append(nt.ncs, conn.NodeConn{URL: "node", RPC: c})
+	}
+	return nt
+}
+
+func TestNodeMonitor(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []*nodeService
+		th    Thresholds
+		err   error
+		ready []bool
+	}{
+		{
+			name:  "ready",
+			nodes: []*nodeService{{head: 10}},
+			th:    Thresholds{MaxHeadAge: time.Minute},
+			ready: []bool{true},
+		}, {
+			name:  "unreachable",
+			nodes: []*nodeService{{down: true}},
+			err:   ErrNodeUnreachable,
+			ready: []bool{false},
+		}, {
+			name:  "syncing",
+			nodes: []*nodeService{{head: 10, syncing: true}},
+			err:   ErrNodeSyncing,
+			ready: []bool{false},
+		}, {
+			name:  "syncing allowed",
+			nodes: []*nodeService{{head: 10, syncing: true}},
+			th:    Thresholds{AllowSyncing: true},
+			ready: []bool{true},
+		}, {
+			name:  "head too old",
+			nodes: []*nodeService{{head: 10, headAge: 2 * time.Minute}},
+			th:    Thresholds{MaxHeadAge: time.Minute},
+			err:   ErrHeadTooOld,
+			ready: []bool{false},
+		}, {
+			name:  "head age not checked",
+			nodes: []*nodeService{{head: 10, headAge: 2 * time.Minute}},
+			ready: []bool{true},
+		}, {
+			name:  "not enough peers",
+			nodes: []*nodeService{{head: 10, peers: 2}},
+			th:    Thresholds{MinPeers: 3},
+			err:   ErrNotEnoughPeers,
+			ready: []bool{false},
+		}, {
+			name:  "one of nodes ready",
+			nodes: []*nodeService{{down: true}, {head: 10, syncing: true}, {head: 10}},
+			ready: []bool{false, false, true},
+		}, {
+			name:  "none of nodes ready reports the first one",
+			nodes: []*nodeService{{head: 10, syncing: true}, {down: true}},
+			err:   ErrNodeSyncing,
+			ready: []bool{false, false},
+		}, {
+			name: "no dialed node",
+			err:  ErrNodeUnreachable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewNodeMonitor("mainnet", newNodesTransport(t, tt.nodes...), tt.th, zap.NewNop())
+
+			err := m.Probe(context.Background())
+			if !errors.Is(err, tt.err) || (err == nil) != (tt.err == nil) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+
+			last := m.Last()
+			if len(last) != len(tt.ready) {
+				t.Fatalf("checks = %+v, want %d", last, len(tt.ready))
+			}
+			for i, nc := range last {
+				if ready := nc.Status == "ok"; ready != tt.ready[i] || ready == (nc.Error != "") {
+					t.Errorf("node %d check = %+v, want ready %v", i, nc, tt.ready[i])
+				}
+			}
+		})
+	}
+}
+
+func TestNodeMonitorReadinessCached(t *testing.T) {
+	ns := &nodeService{head: 10}
+	m := NewNodeMonitor("mainnet", newNodesTransport(t, ns), Thresholds{}, zap.NewNop())
+
+	// nodes are checked on the first readiness request
+	_, _, _, contents, err := m.Readiness(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if nc := contents.([]NodeCheck); len(nc) != 1 || nc[0].Head != 10 {
+		t.Errorf("contents = %+v", contents)
+	}
+
+	// then the result of the last check is reported until the next one
+	ns.down = true
+	if _, _, _, _, err = m.Readiness(context.Background()); err != nil {
+		t.Errorf("readiness error = %v, want the cached result", err)
+	}
+	m.Probe(context.Background())
+	if _, _, _, _, err = m.Readiness(context.Background()); !errors.Is(err, ErrNodeUnreachable) {
+		t.Errorf("readiness error = %v, want %v", err, ErrNodeUnreachable)
+	}
+}
+
+func TestNodeMonitorReadinessEndpoint(t *testing.T) {
+	ns := &nodeService{head: 10, syncing: true, peers: 5}
+	m := NewNodeMonitor("mainnet", newNodesTransport(t, ns), Thresholds{MinPeers: 3}, zap.NewNop())
+	monitor := &health.Monitor{}
+	monitor.AddProber(context.Background(), m)
+	mux := http.NewServeMux()
+	monitor.AttachHttp(mux)
+
+	readiness := func() (int, []NodeCheck) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readiness", nil))
+		var body struct {
+			DB map[string]map[string][]NodeCheck `json:"db"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("invalid readiness response %q: %v", rec.Body.String(), err)
+		}
+		return rec.Code, body.DB["ethereum"]["mainnet"]
+	}
+
+	code, nc := readiness()
+	if code != http.StatusInternalServerError {
+		t.Errorf("status of syncing node = %d, want %d", code, http.StatusInternalServerError)
+	}
+	if len(nc) != 1 || nc[0].Head != 10 || !nc[0].Syncing || nc[0].Peers != 5 || nc[0].Error == "" {
+		t.Errorf("checks = %+v, want the syncing node with its head and peers", nc)
+	}
+
+	ns.syncing = false
+	m.Probe(context.Background())
+	if code, nc = readiness(); code != http.StatusOK || len(nc) != 1 || nc[0].Status != "ok" {
+		t.Errorf("readiness of ready node = %d %+v", code, nc)
+	}
+}
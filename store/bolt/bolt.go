@@ -0,0 +1,179 @@
+package bolt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"github.com/figment-networks/ethereum-worker/store"
+)
+
+// Limit bounds the number of entries of a bucket and their age. Entries are evicted by Compact,
+// the oldest written first. Zero fields do not limit the bucket.
+type Limit struct {
+	Bucket     []byte
+	MaxEntries int
+	TTL        time.Duration
+}
+
+// BoltStore is a Store kept in a single local file. Values of limited buckets are prefixed
+// with their write time and indexed by it in a separate bucket, so the oldest ones are found without a scan.
+type BoltStore struct {
+	db     *bbolt.DB
+	limits map[string]Limit
+}
+
+// NewBoltStore opens or creates the store file at given path
+func NewBoltStore(path string, limits ...Limit) (*BoltStore, error) {
+	// timeout prevents hanging when previous pod still holds the file lock
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 10 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("error opening store %s: %w", path, err)
+	}
+
+	bs := &BoltStore{db: db, limits: make(map[string]Limit, len(limits))}
+	for _, l := range limits {
+		bs.limits[string(l.Bucket)] = l
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, b := range [][]byte{store.BucketDetails, store.BucketResults} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		for _, l := range limits {
+			// entries written without limit have no index, they are dropped as the store is only a cache
+			if tx.Bucket(indexName(l.Bucket)) == nil && tx.Bucket(l.Bucket) != nil {
+				if err := tx.DeleteBucket(l.Bucket); err != nil {
+					return err
+				}
+			}
+			if _, err := tx.CreateBucketIfNotExists(l.Bucket); err != nil {
+				return err
+			}
+			if _, err := tx.CreateBucketIfNotExists(indexName(l.Bucket)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error creating buckets: %w", err)
+	}
+
+	return bs, nil
+}
+
+func indexName(bucket []byte) []byte {
+	return append(append([]byte(nil), bucket...), ".index"...)
+}
+
+// indexKey orders keys by their write time
+func indexKey(written, key []byte) []byte {
+	return append(append(make([]byte, 0, len(written)+len(key)), written...), key...)
+}
+
+// Get returns a copy of the value, as bolt values are valid only within the transaction
+func (bs *BoltStore) Get(bucket, key []byte) (value []byte, err error) {
+	_, limited := bs.limits[string(bucket)]
+	err = bs.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b == nil {
+			return store.ErrNotFound
+		}
+		v := b.Get(key)
+		if v == nil {
+			return store.ErrNotFound
+		}
+		if limited {
+			if len(v) < 8 {
+				return store.ErrNotFound
+			}
+			v = v[8:]
+		}
+		value = append([]byte(nil), v...)
+		return nil
+	})
+	return value, err
+}
+
+func (bs *BoltStore) Put(bucket, key, value []byte) error {
+	_, limited := bs.limits[string(bucket)]
+	return bs.db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(bucket)
+		if err != nil {
+			return err
+		}
+		if !limited {
+			return b.Put(key, value)
+		}
+
+		idx := tx.Bucket(indexName(bucket))
+		if old := b.Get(key); len(old) >= 8 {
+			if err = idx.Delete(indexKey(old[:8], key)); err != nil {
+				return err
+			}
+		}
+
+		written := make([]byte, 8, 8+len(value))
+		binary.BigEndian.PutUint64(written, uint64(time.Now().UnixNano()))
+		if err = idx.Put(indexKey(written, key), nil); err != nil {
+			return err
+		}
+		return b.Put(key, append(written, value...))
+	})
+}
+
+// Compact evicts entries of limited buckets older than their TTL, then the oldest ones over their maximum number.
+// Pages of evicted entries are reused by the following writes, so the file stops growing at the limit.
+func (bs *BoltStore) Compact() (removed int, err error) {
+	err = bs.db.Update(func(tx *bbolt.Tx) error {
+		for _, l := range bs.limits {
+			b, idx := tx.Bucket(l.Bucket), tx.Bucket(indexName(l.Bucket))
+			if b == nil || idx == nil {
+				continue
+			}
+
+			var cutoff []byte
+			if l.TTL > 0 {
+				cutoff = make([]byte, 8)
+				binary.BigEndian.PutUint64(cutoff, uint64(time.Now().Add(-l.TTL).UnixNano()))
+			}
+			over := 0
+			if l.MaxEntries > 0 {
+				over = idx.Stats().KeyN - l.MaxEntries
+			}
+
+			// keys are collected first, as deleting during cursor iteration skips entries
+			var evict [][]byte
+			c := idx.Cursor()
+			for k, _ := c.First(); k != nil; k, _ = c.Next() {
+				if len(evict) >= over && (cutoff == nil || bytes.Compare(k[:8], cutoff) >= 0) {
+					break
+				}
+				evict = append(evict, append([]byte(nil), k...))
+			}
+
+			for _, k := range evict {
+				if err := idx.Delete(k); err != nil {
+					return err
+				}
+				if err := b.Delete(k[8:]); err != nil {
+					return err
+				}
+			}
+			removed += len(evict)
+		}
+		return nil
+	})
+	return removed, err
+}
+
+func (bs *BoltStore) Close() error {
+	return bs.db.Close()
+}
@@ -0,0 +1,157 @@
+package bolt
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/figment-networks/ethereum-worker/store"
+)
+
+func open(t *testing.T, path string, limits ...Limit) *BoltStore {
+	t.Helper()
+	bs, err := NewBoltStore(path, limits...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return bs
+}
+
+func put(t *testing.T, bs *BoltStore, bucket []byte, keys ...string) {
+	t.Helper()
+	for _, k := range keys {
+		if err := bs.Put(bucket, []byte(k), []byte("v"+k)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func keys(t *testing.T, bs *BoltStore, bucket []byte, keys ...string) (found []string) {
+	t.Helper()
+	for _, k := range keys {
+		v, err := bs.Get(bucket, []byte(k))
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+		case err != nil:
+			t.Fatalf("unexpected error: %v", err)
+		case string(v) != "v"+k:
+			t.Fatalf("Get(%s) = %q, want %q", k, v, "v"+k)
+		default:
+			found = append(found, k)
+		}
+	}
+	return found
+}
+
+func TestCompact(t *testing.T) {
+	tests := []struct {
+		name    string
+		limit   Limit
+		put     []string
+		wait    time.Duration
+		after   []string
+		removed int
+		found   []string
+	}{
+		{
+			name:  "under limit",
+			limit: Limit{Bucket: store.BucketResults, MaxEntries: 3},
+			put:   []string{"a", "b", "c"},
+			found: []string{"a", "b", "c"},
+		}, {
+			name:    "oldest over limit",
+			limit:   Limit{Bucket: store.BucketResults, MaxEntries: 2},
+			put:     []string{"a", "b", "c", "d"},
+			removed: 2,
+			found:   []string{"c", "d"},
+		}, {
+			name:    "overwrite makes entry newest",
+			limit:   Limit{Bucket: store.BucketResults, MaxEntries: 2},
+			put:     []string{"a", "b", "c", "a"},
+			removed: 1,
+			found:   []string{"a", "c"},
+		}, {
+			name:    "expired",
+			limit:   Limit{Bucket: store.BucketResults, TTL: 50 * time.Millisecond},
+			put:     []string{"a", "b"},
+			wait:    100 * time.Millisecond,
+			after:   []string{"c"},
+			removed: 2,
+			found:   []string{"c"},
+		}, {
+			name:    "expired and over limit",
+			limit:   Limit{Bucket: store.BucketResults, MaxEntries: 2, TTL: 50 * time.Millisecond},
+			put:     []string{"a"},
+			wait:    100 * time.Millisecond,
+			after:   []string{"b", "c", "d"},
+			removed: 2,
+			found:   []string{"c", "d"},
+		}, {
+			name:  "no limit",
+			limit: Limit{Bucket: store.BucketResults},
+			put:   []string{"a", "b", "c"},
+			found: []string{"a", "b", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := open(t, filepath.Join(t.TempDir(), "store.db"), tt.limit)
+			defer bs.Close()
+
+			put(t, bs, tt.limit.Bucket, tt.put...)
+			time.Sleep(tt.wait)
+			put(t, bs, tt.limit.Bucket, tt.after...)
+			// not limited bucket is left intact
+			put(t, bs, store.BucketDetails, "x", "y", "z")
+
+			removed, err := bs.Compact()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if removed != tt.removed {
+				t.Errorf("removed = %d, want %d", removed, tt.removed)
+			}
+			found := keys(t, bs, tt.limit.Bucket, "a", "b", "c", "d")
+			if fmt.Sprint(found) != fmt.Sprint(tt.found) {
+				t.Errorf("found = %v, want %v", found, tt.found)
+			}
+			if found := keys(t, bs, store.BucketDetails, "x", "y", "z"); len(found) != 3 {
+				t.Errorf("details found = %v, want all", found)
+			}
+			// nothing left to evict
+			if removed, _ = bs.Compact(); removed != 0 {
+				t.Errorf("second compaction removed %d", removed)
+			}
+		})
+	}
+}
+
+func TestLimitedLegacyBucket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.db")
+
+	bs := open(t, path)
+	put(t, bs, store.BucketResults, "a")
+	put(t, bs, store.BucketDetails, "x")
+	bs.Close()
+
+	// results written without limit are not indexed, so they are dropped
+	bs = open(t, path, Limit{Bucket: store.BucketResults, MaxEntries: 10})
+	if found := keys(t, bs, store.BucketResults, "a"); len(found) != 0 {
+		t.Errorf("legacy results found = %v", found)
+	}
+	if found := keys(t, bs, store.BucketDetails, "x"); len(found) != 1 {
+		t.Errorf("details found = %v, want x", found)
+	}
+	put(t, bs, store.BucketResults, "b")
+	bs.Close()
+
+	// indexed results survive reopening
+	bs = open(t, path, Limit{Bucket: store.BucketResults, MaxEntries: 10})
+	defer bs.Close()
+	if found := keys(t, bs, store.BucketResults, "b"); len(found) != 1 {
+		t.Errorf("results found = %v, want b", found)
+	}
+}
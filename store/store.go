@@ -0,0 +1,19 @@
+package store
+
+import "errors"
+
+// ErrNotFound is returned for keys missing in the store
+var ErrNotFound = errors.New("key not found")
+
+// Buckets used by the worker
+var (
+	BucketDetails = []byte("details")
+	BucketResults = []byte("results")
+)
+
+// Store is a persistent key value store, keeping cached data between restarts
+type Store interface {
+	Get(bucket, key []byte) ([]byte, error)
+	Put(bucket, key, value []byte) error
+	Close() error
+}
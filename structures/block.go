@@ -0,0 +1,99 @@
+package structures
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BlockTag is the kind of block selector
+type BlockTag uint8
+
+// BlockLatest is the zero value, so that selector not set reads the latest block
+const (
+	BlockLatest BlockTag = iota
+	BlockNumber
+	BlockSafe
+	BlockFinalized
+	BlockPending
+)
+
+var blockTagNames = map[BlockTag]string{
+	BlockLatest:    "latest",
+	BlockSafe:      "safe",
+	BlockFinalized: "finalized",
+	BlockPending:   "pending",
+}
+
+// BlockSelector selects the block state is read at, either by number or by tag. Zero value selects the latest block.
+type BlockSelector struct {
+	Tag    BlockTag
+	Number uint64
+}
+
+// LatestBlock selects the latest block
+var LatestBlock = BlockSelector{Tag: BlockLatest}
+
+// NumberBlock selects block by its number
+func NumberBlock(number uint64) BlockSelector {
+	return BlockSelector{Tag: BlockNumber, Number: number}
+}
+
+// ParseBlockSelector parses block number or one of latest, safe, finalized and pending tags.
+// Empty string and 0 mean latest.
+func ParseBlockSelector(s string) (BlockSelector, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "latest":
+		return LatestBlock, nil
+	case "safe":
+		return BlockSelector{Tag: BlockSafe}, nil
+	case "finalized":
+		return BlockSelector{Tag: BlockFinalized}, nil
+	case "pending":
+		return BlockSelector{Tag: BlockPending}, nil
+	}
+
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return BlockSelector{}, fmt.Errorf("expected block number or one of latest, safe, finalized, pending: %w", err)
+	}
+	if n == 0 {
+		return LatestBlock, nil
+	}
+	return NumberBlock(n), nil
+}
+
+func (bs BlockSelector) String() string {
+	if bs.Tag == BlockNumber {
+		return strconv.FormatUint(bs.Number, 10)
+	}
+	return blockTagNames[bs.Tag]
+}
+
+// MarshalJSON encodes block numbers as numbers and tags as strings
+func (bs BlockSelector) MarshalJSON() ([]byte, error) {
+	if bs.Tag == BlockNumber {
+		return []byte(strconv.FormatUint(bs.Number, 10)), nil
+	}
+	return json.Marshal(bs.String())
+}
+
+// UnmarshalJSON accepts both numbers and strings, null means latest
+func (bs *BlockSelector) UnmarshalJSON(data []byte) (err error) {
+	if string(data) == "null" {
+		*bs = LatestBlock
+		return nil
+	}
+
+	var s string
+	if len(data) > 0 && data[0] == '"' {
+		if err = json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	} else {
+		s = string(data)
+	}
+	*bs, err = ParseBlockSelector(s)
+	return err
+}
@@ -0,0 +1,109 @@
+package structures
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseBlockSelector(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    BlockSelector
+		wantErr bool
+	}{
+		{in: "", want: LatestBlock},
+		{in: "latest", want: LatestBlock},
+		{in: " Latest ", want: LatestBlock},
+		{in: "0", want: LatestBlock},
+		{in: "safe", want: BlockSelector{Tag: BlockSafe}},
+		{in: "FINALIZED", want: BlockSelector{Tag: BlockFinalized}},
+		{in: "pending", want: BlockSelector{Tag: BlockPending}},
+		{in: "1", want: NumberBlock(1)},
+		{in: "18446744073709551615", want: NumberBlock(18446744073709551615)},
+		{in: "18446744073709551616", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "0x10", wantErr: true},
+		{in: "earliest", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseBlockSelector(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBlockSelector(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseBlockSelector(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlockSelectorZeroValue(t *testing.T) {
+	if (BlockSelector{}) != LatestBlock {
+		t.Errorf("zero value = %v, want latest", BlockSelector{})
+	}
+}
+
+func TestBlockSelectorJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    BlockSelector
+		wantErr bool
+	}{
+		{name: "omitted height", in: `{"accountAddress":"0x01"}`, want: LatestBlock},
+		{name: "null height", in: `{"height":null}`, want: LatestBlock},
+		{name: "zero height", in: `{"height":0}`, want: LatestBlock},
+		{name: "empty height", in: `{"height":""}`, want: LatestBlock},
+		{name: "number", in: `{"height":100}`, want: NumberBlock(100)},
+		{name: "number string", in: `{"height":"100"}`, want: NumberBlock(100)},
+		{name: "tag", in: `{"height":"finalized"}`, want: BlockSelector{Tag: BlockFinalized}},
+		{name: "negative number", in: `{"height":-1}`, wantErr: true},
+		{name: "fraction", in: `{"height":1.5}`, wantErr: true},
+		{name: "unknown tag", in: `{"height":"earliest"}`, wantErr: true},
+		{name: "object", in: `{"height":{}}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req BalanceRequest
+			err := json.Unmarshal([]byte(tt.in), &req)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal(%s) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if !tt.wantErr && req.Height != tt.want {
+				t.Errorf("Unmarshal(%s) height = %v, want %v", tt.in, req.Height, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlockSelectorMarshalJSON(t *testing.T) {
+	tests := []struct {
+		bs   BlockSelector
+		want string
+	}{
+		{bs: BlockSelector{}, want: `"latest"`},
+		{bs: NumberBlock(100), want: `100`},
+		{bs: BlockSelector{Tag: BlockSafe}, want: `"safe"`},
+		{bs: BlockSelector{Tag: BlockPending}, want: `"pending"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			out, err := json.Marshal(tt.bs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("Marshal(%v) = %s, want %s", tt.bs, out, tt.want)
+			}
+
+			var bs BlockSelector
+			if err = json.Unmarshal(out, &bs); err != nil || bs != tt.bs {
+				t.Errorf("Unmarshal(%s) = %v, %v, want %v", out, bs, err, tt.bs)
+			}
+		})
+	}
+}
@@ -1,19 +1,149 @@
 package structures
 
-import "math/big"
+import (
+	"math/big"
+	"time"
+)
 
 type Balance struct {
 	Values  Values  `json:"values"`
 	Details Details `json:"details"`
+	// Block is the block balance was read at
+	Block *Block `json:"block,omitempty"`
+}
+
+// Block identifies the block request was resolved to
+type Block struct {
+	Height uint64 `json:"height"`
+	// Pending is set when the balance was read from the pending state on top of Height
+	Pending bool       `json:"pending,omitempty"`
+	Time    *time.Time `json:"time,omitempty"`
 }
 
 type Details struct {
 	Name     string `json:"name"`
 	Symbol   string `json:"symbol"`
 	Decimals uint64 `json:"decimals"`
+	// Unavailable lists the fields token contract did not provide
+	Unavailable []string `json:"unavailable,omitempty"`
+}
+
+// Unknown reports if none of name, symbol and decimals is available
+func (det Details) Unknown() bool {
+	unavailable := map[string]bool{}
+	for _, f := range det.Unavailable {
+		unavailable[f] = true
+	}
+	return unavailable["name"] && unavailable["symbol"] && unavailable["decimals"]
 }
 
+// Token standards reported in Values Type
+const (
+	TypeNative  = "native"
+	TypeERC20   = "erc20"
+	TypeERC721  = "erc721"
+	TypeERC1155 = "erc1155"
+)
+
 type Values struct {
 	Value big.Int `json:"value"`
 	Type  string  `json:"type"`
+	// TokenID and URI are set for multi token (ERC1155) balances
+	TokenID *big.Int `json:"tokenId,omitempty"`
+	URI     string   `json:"uri,omitempty"`
+}
+
+// NFTOwner is the owner of a single non fungible token
+type NFTOwner struct {
+	TokenID  big.Int `json:"tokenId"`
+	Owner    string  `json:"owner"`
+	TokenURI string  `json:"tokenURI,omitempty"`
+	Details  Details `json:"details"`
+	Block    *Block  `json:"block,omitempty"`
+}
+
+// Network is a network name mapped to ERC20 contract
+type Network struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+	Address string   `json:"address"`
+	Chain   string   `json:"chain,omitempty"`
+	// StartBlock is the block contract was deployed at, balances below it are zero
+	StartBlock uint64 `json:"startBlock,omitempty"`
+	// Overrides replace details read from the contract
+	Overrides *DetailsOverrides `json:"overrides,omitempty"`
+	Details   Details           `json:"details"`
+}
+
+// ChainStatus is a served chain with its identity verified on the node
+type ChainStatus struct {
+	ID      uint64 `json:"id"`
+	Name    string `json:"name,omitempty"`
+	Default bool   `json:"default"`
+	// ChainID and Genesis are read from the node, they are empty until the chain is verified
+	ChainID  uint64     `json:"chainId,omitempty"`
+	Genesis  string     `json:"genesis,omitempty"`
+	Verified *time.Time `json:"verified,omitempty"`
+	// Head is the head received from new heads subscription, it is not set for polled nodes
+	Head *Block `json:"head,omitempty"`
+}
+
+// DetailsOverrides are the details set in configuration instead of the ones read from the contract
+type DetailsOverrides struct {
+	Name     string  `json:"name,omitempty"`
+	Symbol   string  `json:"symbol,omitempty"`
+	Decimals *uint64 `json:"decimals,omitempty"`
+}
+
+// Apply returns details with overridden fields, which are no longer reported as unavailable
+func (o *DetailsOverrides) Apply(det Details) Details {
+	if o == nil {
+		return det
+	}
+
+	overridden := map[string]bool{}
+	if o.Name != "" {
+		det.Name = o.Name
+		overridden["name"] = true
+	}
+	if o.Symbol != "" {
+		det.Symbol = o.Symbol
+		overridden["symbol"] = true
+	}
+	if o.Decimals != nil {
+		det.Decimals = *o.Decimals
+		overridden["decimals"] = true
+	}
+
+	var unavailable []string
+	for _, f := range det.Unavailable {
+		if !overridden[f] {
+			unavailable = append(unavailable, f)
+		}
+	}
+	det.Unavailable = unavailable
+	return det
+}
+
+// BalanceRequest is a single item of balance batch request
+type BalanceRequest struct {
+	AccountAddress  string        `json:"accountAddress"`
+	ContractAddress string        `json:"contractAddress"`
+	Network         string        `json:"network"`
+	Chain           string        `json:"chain"`
+	Height          BlockSelector `json:"height"`
+}
+
+// TotalSupplyRequest is a single item of total supply batch request
+type TotalSupplyRequest struct {
+	ContractAddress string        `json:"contractAddress"`
+	Network         string        `json:"network"`
+	Chain           string        `json:"chain"`
+	Height          BlockSelector `json:"height"`
+}
+
+// BalanceResult is a single item of balance or total supply batch response
+type BalanceResult struct {
+	Balances []Balance `json:"balances,omitempty"`
+	Error    string    `json:"error,omitempty"`
 }
@@ -0,0 +1,67 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	thttp "github.com/figment-networks/ethereum-worker/transport/http"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// AuthInterceptor requires calls to be authenticated by the same api keys and rate limits as HTTP requests,
+// given in x-api-key, x-timestamp and x-signature metadata. Signed calls sign method POST, full method name,
+// timestamp and the request message deterministically marshalled as body. Unauthenticated calls get
+// UNAUTHENTICATED, keys without the scope PERMISSION_DENIED and keys over their rate limit RESOURCE_EXHAUSTED.
+func AuthInterceptor(a *thttp.Authenticator, scope string, logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		get := func(key string) string {
+			if v := md.Get(key); len(v) > 0 {
+				return v[0]
+			}
+			return ""
+		}
+
+		cr := thttp.Credentials{
+			Key:       get(thttp.HeaderAPIKey),
+			Timestamp: get(thttp.HeaderTimestamp),
+			Signature: get(thttp.HeaderSignature),
+		}
+		if auth := get("authorization"); cr.Key == "" && strings.HasPrefix(auth, "Bearer ") {
+			cr.Key = strings.TrimPrefix(auth, "Bearer ")
+		}
+
+		id, err := a.Authorize(scope, cr, "POST", info.FullMethod, func() ([]byte, error) {
+			return signedMessage(req)
+		})
+		if err != nil {
+			logger.Debug("[GRPC] Request rejected", zap.String("key", id), zap.String("method", info.FullMethod), zap.Error(err))
+			switch {
+			case errors.Is(err, thttp.ErrScopeNotAllowed):
+				return nil, status.Error(codes.PermissionDenied, err.Error())
+			case errors.Is(err, thttp.ErrRateLimited):
+				return nil, status.Error(codes.ResourceExhausted, err.Error())
+			default:
+				return nil, status.Error(codes.Unauthenticated, err.Error())
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// signedMessage returns the request message the way clients sign it
+func signedMessage(req interface{}) ([]byte, error) {
+	m, ok := req.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("request %T is not a protobuf message", req)
+	}
+	return proto.MarshalOptions{Deterministic: true}.Marshal(m)
+}
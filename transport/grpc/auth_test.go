@@ -0,0 +1,91 @@
+package grpc
+
+import (
+	"context"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/figment-networks/ethereum-worker/transport/grpc/workerpb"
+	thttp "github.com/figment-networks/ethereum-worker/transport/http"
+)
+
+func TestAuthInterceptor(t *testing.T) {
+	const method = "/ethereumworker.Worker/GetBalance"
+	a, err := thttp.NewAuthenticator([]thttp.APIKey{
+		{ID: "plain", Key: "plain-key"},
+		{ID: "signed", Key: "signed-key", Secret: "secret"},
+		{ID: "admin", Key: "admin-key", Scopes: []string{thttp.ScopeAdmin}},
+		{ID: "limited", Key: "limited-key", RPS: 0.0001, Burst: 1},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	interceptor := AuthInterceptor(a, thttp.ScopeAPI, zap.NewNop())
+	now := time.Now().Unix()
+	ts := strconv.FormatInt(now, 10)
+	req := &workerpb.GetBalanceRequest{AccountAddress: "0xaa", Network: "skale"}
+	signed := func(method string, m proto.Message) string {
+		body, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hex.EncodeToString(thttp.Sign("secret", "POST", method, now, body))
+	}
+
+	tests := []struct {
+		name string
+		md   metadata.MD
+		code codes.Code
+	}{
+		{name: "missing key", md: metadata.MD{}, code: codes.Unauthenticated},
+		{name: "unknown key", md: metadata.Pairs("x-api-key", "other"), code: codes.Unauthenticated},
+		{name: "key", md: metadata.Pairs("x-api-key", "plain-key"), code: codes.OK},
+		{name: "bearer token", md: metadata.Pairs("authorization", "Bearer plain-key"), code: codes.OK},
+		{name: "key without scope", md: metadata.Pairs("x-api-key", "admin-key"), code: codes.PermissionDenied},
+		{
+			name: "signed",
+			md:   metadata.Pairs("x-api-key", "signed-key", "x-timestamp", ts, "x-signature", signed(method, req)),
+			code: codes.OK,
+		}, {
+			name: "other method signed",
+			md:   metadata.Pairs("x-api-key", "signed-key", "x-timestamp", ts, "x-signature", signed("/ethereumworker.Worker/GetBalances", req)),
+			code: codes.Unauthenticated,
+		}, {
+			name: "other message signed",
+			md:   metadata.Pairs("x-api-key", "signed-key", "x-timestamp", ts, "x-signature", signed(method, &workerpb.GetBalanceRequest{AccountAddress: "0xbb", Network: "skale"})),
+			code: codes.Unauthenticated,
+		}, {
+			name: "message not signed",
+			md:   metadata.Pairs("x-api-key", "signed-key", "x-timestamp", ts, "x-signature", hex.EncodeToString(thttp.Sign("secret", "POST", method, now, nil))),
+			code: codes.Unauthenticated,
+		},
+		{name: "within quota", md: metadata.Pairs("x-api-key", "limited-key"), code: codes.OK},
+		{name: "quota exhausted", md: metadata.Pairs("x-api-key", "limited-key"), code: codes.ResourceExhausted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called bool
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			_, err := interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				return nil, nil
+			})
+			if code := status.Code(err); code != tt.code {
+				t.Errorf("code = %s, want %s", code, tt.code)
+			}
+			if called != (tt.code == codes.OK) {
+				t.Errorf("handler called = %v", called)
+			}
+		})
+	}
+}
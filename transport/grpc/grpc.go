@@ -0,0 +1,235 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/figment-networks/ethereum-worker/client"
+	"github.com/figment-networks/ethereum-worker/structures"
+	"github.com/figment-networks/ethereum-worker/transport"
+	"github.com/figment-networks/ethereum-worker/transport/grpc/workerpb"
+	"github.com/figment-networks/indexing-engine/metrics"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxBatchSize is the maximum number of items in a single batch request
+const maxBatchSize = 1000
+
+var (
+	getBalanceDuration       *metrics.GroupObserver
+	getBalancesDuration      *metrics.GroupObserver
+	getTotalSupplyDuration   *metrics.GroupObserver
+	getTotalSuppliesDuration *metrics.GroupObserver
+)
+
+// Connector is main gRPC connector for manager
+type Connector struct {
+	workerpb.UnimplementedWorkerServer
+
+	cli    transport.RetrieveClienter
+	logger *zap.Logger
+}
+
+// NewConnector is Connector constructor
+func NewConnector(cli transport.RetrieveClienter, logger *zap.Logger) *Connector {
+	getBalanceDuration = endpointDuration.WithLabels("getBalance")
+	getBalancesDuration = endpointDuration.WithLabels("getBalances")
+	getTotalSupplyDuration = endpointDuration.WithLabels("getTotalSupply")
+	getTotalSuppliesDuration = endpointDuration.WithLabels("getTotalSupplies")
+	return &Connector{cli: cli, logger: logger}
+}
+
+// Register registers Worker service in grpc server
+func (c *Connector) Register(s *grpc.Server) {
+	workerpb.RegisterWorkerServer(s, c)
+}
+
+// GetBalance is grpc handler for GetBalance method
+func (c *Connector) GetBalance(ctx context.Context, req *workerpb.GetBalanceRequest) (*workerpb.GetBalanceResponse, error) {
+	timer := metrics.NewTimer(getBalanceDuration)
+	defer timer.ObserveDuration()
+
+	if req.AccountAddress == "" {
+		return nil, status.Error(codes.InvalidArgument, "AccountAddress must be set")
+	}
+
+	if req.Network == "" && req.ContractAddress == "" {
+		return nil, status.Error(codes.InvalidArgument, "Either network or contractAddress must be set")
+	}
+
+	bs, err := blockSelector(req.Height, req.BlockTag)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid block_tag: "+err.Error())
+	}
+
+	b, err := c.cli.GetAccountBalance(ctx, req.Chain, req.Network, req.ContractAddress, req.AccountAddress, bs)
+	if err != nil {
+		return nil, c.statusFromError(err, "Error processing account request")
+	}
+
+	return &workerpb.GetBalanceResponse{Balances: balancesToPb(b)}, nil
+}
+
+// GetBalances is grpc handler for GetBalances method
+func (c *Connector) GetBalances(ctx context.Context, req *workerpb.GetBalancesRequest) (*workerpb.GetBalancesResponse, error) {
+	timer := metrics.NewTimer(getBalancesDuration)
+	defer timer.ObserveDuration()
+
+	if len(req.Requests) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "At least one request must be set")
+	}
+
+	if len(req.Requests) > maxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "Too many requests in batch, maximum is %d", maxBatchSize)
+	}
+
+	reqs := make([]structures.BalanceRequest, len(req.Requests))
+	for i, r := range req.Requests {
+		bs, err := blockSelector(r.Height, r.BlockTag)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid block_tag of request %d: %s", i, err.Error())
+		}
+		reqs[i] = structures.BalanceRequest{
+			AccountAddress:  r.AccountAddress,
+			ContractAddress: r.ContractAddress,
+			Network:         r.Network,
+			Chain:           r.Chain,
+			Height:          bs,
+		}
+	}
+
+	return &workerpb.GetBalancesResponse{Results: resultsToPb(c.cli.GetAccountBalances(ctx, reqs))}, nil
+}
+
+// GetTotalSupply is grpc handler for GetTotalSupply method
+func (c *Connector) GetTotalSupply(ctx context.Context, req *workerpb.GetTotalSupplyRequest) (*workerpb.GetTotalSupplyResponse, error) {
+	timer := metrics.NewTimer(getTotalSupplyDuration)
+	defer timer.ObserveDuration()
+
+	if req.Network == "" && req.ContractAddress == "" {
+		return nil, status.Error(codes.InvalidArgument, "Either network or contractAddress must be set")
+	}
+
+	bs, err := blockSelector(req.Height, req.BlockTag)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid block_tag: "+err.Error())
+	}
+
+	b, err := c.cli.GetERC20TotalSupply(ctx, req.Chain, req.Network, req.ContractAddress, bs)
+	if err != nil {
+		return nil, c.statusFromError(err, "Error processing total supply request")
+	}
+
+	return &workerpb.GetTotalSupplyResponse{Balances: balancesToPb(b)}, nil
+}
+
+// GetTotalSupplies is grpc handler for GetTotalSupplies method
+func (c *Connector) GetTotalSupplies(ctx context.Context, req *workerpb.GetTotalSuppliesRequest) (*workerpb.GetTotalSuppliesResponse, error) {
+	timer := metrics.NewTimer(getTotalSuppliesDuration)
+	defer timer.ObserveDuration()
+
+	if len(req.Requests) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "At least one request must be set")
+	}
+
+	if len(req.Requests) > maxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "Too many requests in batch, maximum is %d", maxBatchSize)
+	}
+
+	reqs := make([]structures.TotalSupplyRequest, len(req.Requests))
+	for i, r := range req.Requests {
+		bs, err := blockSelector(r.Height, r.BlockTag)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "Invalid block_tag of request %d: %s", i, err.Error())
+		}
+		reqs[i] = structures.TotalSupplyRequest{
+			ContractAddress: r.ContractAddress,
+			Network:         r.Network,
+			Chain:           r.Chain,
+			Height:          bs,
+		}
+	}
+
+	return &workerpb.GetTotalSuppliesResponse{Results: resultsToPb(c.cli.GetERC20TotalSupplies(ctx, reqs))}, nil
+}
+
+// blockSelector returns block selected by tag, or by height when tag is not set. Height 0 means latest.
+func blockSelector(height uint64, tag string) (structures.BlockSelector, error) {
+	if tag != "" {
+		return structures.ParseBlockSelector(tag)
+	}
+	if height == 0 {
+		return structures.LatestBlock, nil
+	}
+	return structures.NumberBlock(height), nil
+}
+
+// statusFromError returns the status of an error returned by the client: invalid chain selection is InvalidArgument,
+// height not available OutOfRange, exhausted upstream quota ResourceExhausted and unavailable node Unavailable.
+// Other errors are logged and returned as Internal with msg.
+func (c *Connector) statusFromError(err error, msg string) error {
+	switch {
+	case isChainError(err):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, client.ErrHeightNotAvailable):
+		return status.Error(codes.OutOfRange, err.Error())
+	case client.IsQuotaExhausted(err):
+		c.logger.Warn("Upstream quota exhausted", zap.Error(err))
+		return status.Error(codes.ResourceExhausted, "Upstream quota exhausted, retry later")
+	case client.IsNodeUnavailable(err):
+		c.logger.Warn("Ethereum node unavailable", zap.Error(err))
+		return status.Error(codes.Unavailable, "Ethereum node unavailable, retry later")
+	default:
+		c.logger.Error(msg, zap.Error(err))
+		return status.Error(codes.Internal, msg)
+	}
+}
+
+// isChainError checks if err is caused by invalid chain selection
+func isChainError(err error) bool {
+	return errors.Is(err, client.ErrUnknownChain) || errors.Is(err, client.ErrChainMismatch)
+}
+
+func balancesToPb(bs []structures.Balance) []*workerpb.Balance {
+	pbs := make([]*workerpb.Balance, len(bs))
+	for i, b := range bs {
+		pbs[i] = &workerpb.Balance{
+			Values: &workerpb.Values{
+				Value: b.Values.Value.String(),
+				Type:  b.Values.Type,
+				Uri:   b.Values.URI,
+			},
+			Details: &workerpb.Details{
+				Name:        b.Details.Name,
+				Symbol:      b.Details.Symbol,
+				Decimals:    b.Details.Decimals,
+				Unavailable: b.Details.Unavailable,
+			},
+		}
+		if b.Values.TokenID != nil {
+			pbs[i].Values.TokenId = b.Values.TokenID.String()
+		}
+		if b.Block != nil {
+			pbs[i].Block = &workerpb.Block{Height: b.Block.Height, Pending: b.Block.Pending}
+			if b.Block.Time != nil {
+				pbs[i].Block.Time = b.Block.Time.Unix()
+			}
+		}
+	}
+	return pbs
+}
+
+func resultsToPb(rs []structures.BalanceResult) []*workerpb.BalanceResult {
+	pbs := make([]*workerpb.BalanceResult, len(rs))
+	for i, r := range rs {
+		pbs[i] = &workerpb.BalanceResult{
+			Balances: balancesToPb(r.Balances),
+			Error:    r.Error,
+		}
+	}
+	return pbs
+}
@@ -0,0 +1,120 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+	"github.com/figment-networks/ethereum-worker/client"
+	"github.com/figment-networks/ethereum-worker/structures"
+	"github.com/figment-networks/ethereum-worker/transport"
+	"github.com/figment-networks/ethereum-worker/transport/grpc/workerpb"
+)
+
+// fakeClient returns err or a balance of 1 and records the block it was asked for
+type fakeClient struct {
+	transport.RetrieveClienter
+
+	err error
+	bs  structures.BlockSelector
+}
+
+func (fc *fakeClient) GetAccountBalance(ctx context.Context, chain, network, contract, address string, bs structures.BlockSelector) ([]structures.Balance, error) {
+	fc.bs = bs
+	if fc.err != nil {
+		return nil, fc.err
+	}
+	return []structures.Balance{{Values: structures.Values{Value: *big.NewInt(1), Type: structures.TypeERC20}}}, nil
+}
+
+func TestGetBalance(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *workerpb.GetBalanceRequest
+		err  error
+		code codes.Code
+		bs   structures.BlockSelector
+	}{
+		{
+			name: "latest block",
+			req:  &workerpb.GetBalanceRequest{AccountAddress: "0xbb", Network: "skale"},
+			code: codes.OK,
+			bs:   structures.LatestBlock,
+		}, {
+			name: "height",
+			req:  &workerpb.GetBalanceRequest{AccountAddress: "0xbb", Network: "skale", Height: 10},
+			code: codes.OK,
+			bs:   structures.NumberBlock(10),
+		}, {
+			name: "block tag takes precedence",
+			req:  &workerpb.GetBalanceRequest{AccountAddress: "0xbb", Network: "skale", Height: 10, BlockTag: "finalized"},
+			code: codes.OK,
+			bs:   structures.BlockSelector{Tag: structures.BlockFinalized},
+		}, {
+			name: "invalid block tag",
+			req:  &workerpb.GetBalanceRequest{AccountAddress: "0xbb", Network: "skale", BlockTag: "earliest"},
+			code: codes.InvalidArgument,
+		}, {
+			name: "missing account",
+			req:  &workerpb.GetBalanceRequest{Network: "skale"},
+			code: codes.InvalidArgument,
+		}, {
+			name: "missing network and contract",
+			req:  &workerpb.GetBalanceRequest{AccountAddress: "0xbb"},
+			code: codes.InvalidArgument,
+		}, {
+			name: "unknown chain",
+			req:  &workerpb.GetBalanceRequest{AccountAddress: "0xbb", Network: "skale", Chain: "goerli"},
+			err:  fmt.Errorf("%w: goerli", client.ErrUnknownChain),
+			code: codes.InvalidArgument,
+		}, {
+			name: "pruned height",
+			req:  &workerpb.GetBalanceRequest{AccountAddress: "0xbb", Network: "skale", Height: 1},
+			err:  fmt.Errorf("%w: block 1", client.ErrHeightNotAvailable),
+			code: codes.OutOfRange,
+		}, {
+			name: "quota exhausted",
+			req:  &workerpb.GetBalanceRequest{AccountAddress: "0xbb", Network: "skale"},
+			err:  fmt.Errorf("error calling Balanceof: %w", conn.ErrQuotaExhausted),
+			code: codes.ResourceExhausted,
+		}, {
+			name: "nodes unavailable",
+			req:  &workerpb.GetBalanceRequest{AccountAddress: "0xbb", Network: "skale"},
+			err:  fmt.Errorf("error calling Balanceof: %w", conn.ErrCircuitOpen),
+			code: codes.Unavailable,
+		}, {
+			name: "other error",
+			req:  &workerpb.GetBalanceRequest{AccountAddress: "0xbb", Network: "skale"},
+			err:  errors.New("abi: cannot unmarshal"),
+			code: codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc := &fakeClient{err: tt.err}
+			c := NewConnector(fc, zap.NewNop())
+
+			resp, err := c.GetBalance(context.Background(), tt.req)
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("code = %s, want %s (%v)", code, tt.code, err)
+			}
+			if tt.code != codes.OK {
+				return
+			}
+			if fc.bs != tt.bs {
+				t.Errorf("block = %v, want %v", fc.bs, tt.bs)
+			}
+			if len(resp.Balances) != 1 || resp.Balances[0].Values.Value != "1" {
+				t.Errorf("balances = %v, want one of value 1", resp.Balances)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package grpc
+
+import "github.com/figment-networks/indexing-engine/metrics"
+
+var (
+	endpointDuration = metrics.MustNewHistogramWithTags(metrics.HistogramOptions{
+		Namespace: "indexerworkerlive",
+		Subsystem: "grpc",
+		Name:      "endpoint_duration",
+		Desc:      "Duration how long it takes for each endpoint",
+		Tags:      []string{"type"},
+	})
+)
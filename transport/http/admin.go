@@ -0,0 +1,107 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/figment-networks/ethereum-worker/client"
+	"github.com/figment-networks/ethereum-worker/structures"
+	"github.com/figment-networks/ethereum-worker/transport"
+	"github.com/figment-networks/indexing-engine/metrics"
+	"go.uber.org/zap"
+)
+
+var networksDuration *metrics.GroupObserver
+
+// AttachAdminToHandler attaches network registry administration handlers to http server's mux
+func (c *Connector) AttachAdminToHandler(mux *http.ServeMux, admin transport.NetworkAdminer) {
+	c.admin = admin
+	mux.HandleFunc("/admin/networks", c.Networks)
+}
+
+// Networks is http handler of network registry. GET lists networks, POST adds new network,
+// PUT replaces definition of existing network and DELETE removes network given in name param.
+// Networks' aliases, chain, start block and overrides are set with POST and PUT, chain not set in PUT is kept.
+func (c *Connector) Networks(w http.ResponseWriter, req *http.Request) {
+	timer := metrics.NewTimer(networksDuration)
+	defer timer.ObserveDuration()
+
+	enc := json.NewEncoder(w)
+	switch req.Method {
+	case http.MethodGet:
+		w.WriteHeader(http.StatusOK)
+		if err := enc.Encode(c.admin.ListNetworks()); err != nil {
+			c.logger.Error("Error encoding response", zap.Error(err))
+		}
+		return
+	case http.MethodDelete:
+		name := req.URL.Query().Get("name")
+		if name == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			enc.Encode(ServiceError{Msg: "Name must be set"})
+			return
+		}
+		if err := c.admin.RemoveNetwork(name); err != nil {
+			c.writeNetworkError(w, enc, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case http.MethodPost, http.MethodPut:
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		enc.Encode(ServiceError{Msg: "Method must be GET, POST, PUT or DELETE"})
+		return
+	}
+
+	n := structures.Network{}
+	dec := json.NewDecoder(req.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&n); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "Invalid request body: " + err.Error()})
+		return
+	}
+	if n.Name == "" || n.Address == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "Name and address must be set"})
+		return
+	}
+
+	var err error
+	status := http.StatusOK
+	if req.Method == http.MethodPost {
+		status = http.StatusCreated
+		n, err = c.admin.AddNetwork(req.Context(), n)
+	} else {
+		n, err = c.admin.UpdateNetwork(req.Context(), n)
+	}
+	if err != nil {
+		c.writeNetworkError(w, enc, err)
+		return
+	}
+
+	w.WriteHeader(status)
+	if err = enc.Encode(n); err != nil {
+		c.logger.Error("Error encoding response", zap.Error(err))
+	}
+}
+
+func (c *Connector) writeNetworkError(w http.ResponseWriter, enc *json.Encoder, err error) {
+	switch {
+	case errors.Is(err, client.ErrNetworkExists):
+		w.WriteHeader(http.StatusConflict)
+		enc.Encode(ServiceError{Msg: err.Error()})
+	case errors.Is(err, client.ErrNetworkNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		enc.Encode(ServiceError{Msg: err.Error()})
+	case errors.Is(err, client.ErrInvalidNetwork), errors.Is(err, client.ErrUnknownChain):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		enc.Encode(ServiceError{Msg: err.Error()})
+	default:
+		c.logger.Error("Error processing network request", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		enc.Encode(ServiceError{Msg: "Error processing network request"})
+	}
+}
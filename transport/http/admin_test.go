@@ -0,0 +1,103 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/figment-networks/ethereum-worker/client"
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+// fakeAdmin records the network it was given and returns it, or err
+type fakeAdmin struct {
+	method string
+	n      structures.Network
+	err    error
+}
+
+func (fa *fakeAdmin) ListNetworks() []structures.Network {
+	return []structures.Network{{Name: "token"}}
+}
+
+func (fa *fakeAdmin) AddNetwork(ctx context.Context, n structures.Network) (structures.Network, error) {
+	fa.method, fa.n = "add", n
+	return n, fa.err
+}
+
+func (fa *fakeAdmin) UpdateNetwork(ctx context.Context, n structures.Network) (structures.Network, error) {
+	fa.method, fa.n = "update", n
+	return n, fa.err
+}
+
+func (fa *fakeAdmin) RemoveNetwork(name string) error {
+	fa.method, fa.n = "remove", structures.Network{Name: name}
+	return fa.err
+}
+
+func TestNetworks(t *testing.T) {
+	decimals := uint64(8)
+	full := `{"name":"token","aliases":["tkn"],"address":"0xaa","chain":"goerli","startBlock":3,"overrides":{"decimals":8}}`
+	fullNetwork := structures.Network{
+		Name:       "token",
+		Aliases:    []string{"tkn"},
+		Address:    "0xaa",
+		Chain:      "goerli",
+		StartBlock: 3,
+		Overrides:  &structures.DetailsOverrides{Decimals: &decimals},
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		body    string
+		err     error
+		status  int
+		called  string
+		network structures.Network
+	}{
+		{name: "list", method: http.MethodGet, status: http.StatusOK},
+		{name: "add", method: http.MethodPost, body: full, status: http.StatusCreated, called: "add", network: fullNetwork},
+		{name: "update", method: http.MethodPut, body: full, status: http.StatusOK, called: "update", network: fullNetwork},
+		{name: "remove", method: http.MethodDelete, target: "?name=token", status: http.StatusNoContent, called: "remove", network: structures.Network{Name: "token"}},
+		{name: "remove without name", method: http.MethodDelete, status: http.StatusBadRequest},
+		{name: "unknown field", method: http.MethodPost, body: `{"name":"token","address":"0xaa","start_block":3}`, status: http.StatusBadRequest},
+		{name: "missing address", method: http.MethodPut, body: `{"name":"token"}`, status: http.StatusBadRequest},
+		{name: "invalid body", method: http.MethodPost, body: `[]`, status: http.StatusBadRequest},
+		{name: "method not allowed", method: http.MethodPatch, status: http.StatusMethodNotAllowed},
+		{name: "exists", method: http.MethodPost, body: full, err: fmt.Errorf("%w: tkn", client.ErrNetworkExists), status: http.StatusConflict, called: "add", network: fullNetwork},
+		{name: "not found", method: http.MethodPut, body: full, err: client.ErrNetworkNotFound, status: http.StatusNotFound, called: "update", network: fullNetwork},
+		{name: "invalid contract", method: http.MethodPost, body: full, err: client.ErrInvalidNetwork, status: http.StatusUnprocessableEntity, called: "add", network: fullNetwork},
+		{name: "unknown chain", method: http.MethodPost, body: full, err: client.ErrUnknownChain, status: http.StatusUnprocessableEntity, called: "add", network: fullNetwork},
+		{name: "other error", method: http.MethodPost, body: full, err: fmt.Errorf("node down"), status: http.StatusInternalServerError, called: "add", network: fullNetwork},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fa := &fakeAdmin{err: tt.err}
+			c := NewConnector(nil, zap.NewNop())
+			mux := http.NewServeMux()
+			c.AttachAdminToHandler(mux, fa)
+
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest(tt.method, "/admin/networks"+tt.target, strings.NewReader(tt.body)))
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.status, w.Body)
+			}
+			if fa.method != tt.called {
+				t.Errorf("called = %q, want %q", fa.method, tt.called)
+			}
+			if !reflect.DeepEqual(fa.n, tt.network) {
+				t.Errorf("network = %+v, want %+v", fa.n, tt.network)
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package http
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/figment-networks/indexing-engine/metrics"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// Scopes of endpoints keys may access
+const (
+	ScopeAPI     = "api"
+	ScopeAdmin   = "admin"
+	ScopeHealth  = "health"
+	ScopeMetrics = "metrics"
+)
+
+// Authentication headers. Signed requests carry the key in HeaderAPIKey as well.
+const (
+	HeaderAPIKey    = "X-API-Key"
+	HeaderTimestamp = "X-Timestamp"
+	HeaderSignature = "X-Signature"
+)
+
+// maxSignedBodySize is the maximum size of signed request body
+const maxSignedBodySize = 10 << 20
+
+var (
+	ErrMissingKey       = errors.New("api key must be set")
+	ErrUnknownKey       = errors.New("unknown api key")
+	ErrInvalidSignature = errors.New("invalid request signature")
+	ErrExpiredSignature = errors.New("request timestamp is out of allowed skew")
+	ErrScopeNotAllowed  = errors.New("api key is not allowed to access this endpoint")
+	ErrRateLimited      = errors.New("api key rate limit exceeded")
+	ErrBodyTooLarge     = fmt.Errorf("signed request body is larger than %d bytes", maxSignedBodySize)
+)
+
+var authRequests = metrics.MustNewCounterWithTags(metrics.Options{
+	Namespace: "indexerworkerlive",
+	Subsystem: "auth",
+	Name:      "requests",
+	Desc:      "Number of authenticated requests by api key, scope and result",
+	Tags:      []string{"key", "scope", "result"},
+})
+
+// APIKey is a key of API client
+type APIKey struct {
+	// ID identifies the key in metrics and logs, the key itself is never reported
+	ID  string
+	Key string
+	// Secret is HMAC-SHA256 secret, when set requests have to be signed with it
+	Secret string
+	// RPS is the limit of key's requests per second, zero does not limit them
+	RPS   float64
+	Burst int
+	// Scopes the key may access, ScopeAPI when empty
+	Scopes []string
+}
+
+type authKey struct {
+	APIKey
+	scopes  map[string]bool
+	limiter *rate.Limiter
+}
+
+// Authenticator authenticates requests with API keys, optionally signed with HMAC-SHA256.
+// Signature is hex encoded HMAC of method, request uri, timestamp and body separated by new lines,
+// timestamp is unix seconds that may differ from server time by MaxSkew at most.
+type Authenticator struct {
+	// MaxSkew is the maximum difference of signed request timestamp and server time
+	MaxSkew time.Duration
+
+	keys   map[string]*authKey
+	logger *zap.Logger
+}
+
+// NewAuthenticator is Authenticator constructor
+func NewAuthenticator(keys []APIKey, logger *zap.Logger) (*Authenticator, error) {
+	a := &Authenticator{
+		MaxSkew: 5 * time.Minute,
+		keys:    make(map[string]*authKey, len(keys)),
+		logger:  logger,
+	}
+
+	ids := map[string]bool{}
+	for _, k := range keys {
+		if k.ID == "" || k.Key == "" {
+			return nil, errors.New("api key id and key must be set")
+		}
+		if ids[k.ID] || a.keys[k.Key] != nil {
+			return nil, fmt.Errorf("api key %s is defined more than once", k.ID)
+		}
+		ids[k.ID] = true
+
+		ak := &authKey{APIKey: k, scopes: map[string]bool{}}
+		for _, s := range k.Scopes {
+			switch s {
+			case ScopeAPI, ScopeAdmin, ScopeHealth, ScopeMetrics:
+				ak.scopes[s] = true
+			default:
+				return nil, fmt.Errorf("api key %s has unknown scope %q", k.ID, s)
+			}
+		}
+		if len(ak.scopes) == 0 {
+			ak.scopes[ScopeAPI] = true
+		}
+		if k.RPS > 0 {
+			burst := k.Burst
+			if burst < 1 {
+				burst = 1
+			}
+			ak.limiter = rate.NewLimiter(rate.Limit(k.RPS), burst)
+		}
+		a.keys[k.Key] = ak
+	}
+	return a, nil
+}
+
+// Credentials authenticate a request, they are read from headers of HTTP requests and metadata of gRPC calls
+type Credentials struct {
+	Key       string
+	Timestamp string
+	Signature string
+}
+
+// Protect requires requests to h to be authenticated with key allowed to access the scope.
+// Unauthenticated requests get 401, keys without the scope 403, keys over their rate limit 429
+// and signed requests with body over maxSignedBodySize 413.
+func (a *Authenticator) Protect(scope string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		cr := Credentials{
+			Key:       req.Header.Get(HeaderAPIKey),
+			Timestamp: req.Header.Get(HeaderTimestamp),
+			Signature: req.Header.Get(HeaderSignature),
+		}
+		if auth := req.Header.Get("Authorization"); cr.Key == "" && strings.HasPrefix(auth, "Bearer ") {
+			cr.Key = strings.TrimPrefix(auth, "Bearer ")
+		}
+
+		id, err := a.Authorize(scope, cr, req.Method, req.URL.RequestURI(), func() ([]byte, error) {
+			return readBody(req)
+		})
+		if err != nil {
+			a.reject(w, req, id, err)
+			return
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
+// Authorize authenticates the request and checks its key may access the scope within the key's rate limit.
+// Keys with secret have to sign method, request uri, timestamp and body, which is read only then.
+// Errors are ErrScopeNotAllowed, ErrRateLimited, ErrBodyTooLarge or the authentication ones. Id of the key is returned
+// for logging, once it is known.
+func (a *Authenticator) Authorize(scope string, cr Credentials, method, requestURI string, body func() ([]byte, error)) (id string, err error) {
+	id, result := "unknown", "unauthorized"
+	defer func() {
+		if err == nil {
+			result = "ok"
+		}
+		authRequests.WithLabels(id, scope, result).Inc()
+	}()
+
+	if cr.Key == "" {
+		return id, ErrMissingKey
+	}
+	k, ok := a.keys[cr.Key]
+	if !ok {
+		return id, ErrUnknownKey
+	}
+	id = k.ID
+	if k.Secret != "" {
+		if err := a.verifySignature(k.Secret, cr, method, requestURI, body); err != nil {
+			if errors.Is(err, ErrBodyTooLarge) {
+				result = "too_large"
+			}
+			return id, err
+		}
+	}
+	if !k.scopes[scope] {
+		result = "forbidden"
+		return id, ErrScopeNotAllowed
+	}
+	if k.limiter != nil && !k.limiter.Allow() {
+		result = "rate_limited"
+		return id, ErrRateLimited
+	}
+	return id, nil
+}
+
+func (a *Authenticator) reject(w http.ResponseWriter, req *http.Request, id string, err error) {
+	a.logger.Debug("[HTTP] Request rejected", zap.String("key", id), zap.String("path", req.URL.Path), zap.Error(err))
+
+	status := http.StatusUnauthorized
+	switch {
+	case errors.Is(err, ErrScopeNotAllowed):
+		status = http.StatusForbidden
+	case errors.Is(err, ErrRateLimited):
+		status = http.StatusTooManyRequests
+	case errors.Is(err, ErrBodyTooLarge):
+		status = http.StatusRequestEntityTooLarge
+	default:
+		w.Header().Set("WWW-Authenticate", "ApiKey")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ServiceError{Status: status, Msg: err.Error()})
+}
+
+func (a *Authenticator) verifySignature(secret string, cr Credentials, method, requestURI string, body func() ([]byte, error)) error {
+	ts, err := strconv.ParseInt(cr.Timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %s header must be unix time", ErrInvalidSignature, HeaderTimestamp)
+	}
+	if skew := time.Since(time.Unix(ts, 0)); skew > a.MaxSkew || skew < -a.MaxSkew {
+		return ErrExpiredSignature
+	}
+
+	signature, err := hex.DecodeString(cr.Signature)
+	if err != nil || len(signature) == 0 {
+		return fmt.Errorf("%w: %s header must be hex encoded", ErrInvalidSignature, HeaderSignature)
+	}
+
+	b, err := body()
+	if err != nil {
+		return fmt.Errorf("error reading request body: %w", err)
+	}
+	if !hmac.Equal(signature, Sign(secret, method, requestURI, ts, b)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// readBody reads the body of signed request and replaces it, so the handler can read it again.
+// Bodies over maxSignedBodySize are rejected rather than verified and served truncated.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, maxSignedBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxSignedBodySize {
+		return nil, ErrBodyTooLarge
+	}
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// Sign returns HMAC-SHA256 signature of the request
+func Sign(secret, method, requestURI string, timestamp int64, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s\n%s\n%d\n", method, requestURI, timestamp)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
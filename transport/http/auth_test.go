@@ -0,0 +1,241 @@
+package http
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func testAuthenticator(t *testing.T) *Authenticator {
+	a, err := NewAuthenticator([]APIKey{
+		{ID: "plain", Key: "plain-key"},
+		{ID: "signed", Key: "signed-key", Secret: "secret"},
+		{ID: "admin", Key: "admin-key", Scopes: []string{ScopeAdmin}},
+		{ID: "limited", Key: "limited-key", RPS: 0.0001, Burst: 2},
+	}, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestProtect(t *testing.T) {
+	const target = "/getBalance?network=skale&accountAddress=0xaa"
+	now := time.Now().Unix()
+	sign := func(method, uri string, ts int64, body string) string {
+		return hex.EncodeToString(Sign("secret", method, uri, ts, []byte(body)))
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		body    string
+		headers map[string]string
+		status  int
+	}{
+		{
+			name:   "missing key",
+			method: http.MethodGet,
+			status: http.StatusUnauthorized,
+		}, {
+			name:    "unknown key",
+			method:  http.MethodGet,
+			headers: map[string]string{HeaderAPIKey: "other"},
+			status:  http.StatusUnauthorized,
+		}, {
+			name:    "key",
+			method:  http.MethodGet,
+			headers: map[string]string{HeaderAPIKey: "plain-key"},
+			status:  http.StatusOK,
+		}, {
+			name:    "bearer token",
+			method:  http.MethodGet,
+			headers: map[string]string{"Authorization": "Bearer plain-key"},
+			status:  http.StatusOK,
+		}, {
+			name:    "key without scope",
+			method:  http.MethodGet,
+			headers: map[string]string{HeaderAPIKey: "admin-key"},
+			status:  http.StatusForbidden,
+		}, {
+			name:   "signed",
+			method: http.MethodGet,
+			headers: map[string]string{
+				HeaderAPIKey:    "signed-key",
+				HeaderTimestamp: strconv.FormatInt(now, 10),
+				HeaderSignature: sign(http.MethodGet, target, now, ""),
+			},
+			status: http.StatusOK,
+		}, {
+			name:   "signed body",
+			method: http.MethodPost,
+			body:   `{"requests":[]}`,
+			headers: map[string]string{
+				HeaderAPIKey:    "signed-key",
+				HeaderTimestamp: strconv.FormatInt(now, 10),
+				HeaderSignature: sign(http.MethodPost, target, now, `{"requests":[]}`),
+			},
+			status: http.StatusOK,
+		}, {
+			name:   "changed body",
+			method: http.MethodPost,
+			body:   `{"requests":[{}]}`,
+			headers: map[string]string{
+				HeaderAPIKey:    "signed-key",
+				HeaderTimestamp: strconv.FormatInt(now, 10),
+				HeaderSignature: sign(http.MethodPost, target, now, `{"requests":[]}`),
+			},
+			status: http.StatusUnauthorized,
+		}, {
+			name:   "signed body too large",
+			method: http.MethodPost,
+			body:   strings.Repeat("a", maxSignedBodySize+1),
+			headers: map[string]string{
+				HeaderAPIKey:    "signed-key",
+				HeaderTimestamp: strconv.FormatInt(now, 10),
+				HeaderSignature: sign(http.MethodPost, target, now, strings.Repeat("a", maxSignedBodySize)),
+			},
+			status: http.StatusRequestEntityTooLarge,
+		}, {
+			name:   "other method signed",
+			method: http.MethodGet,
+			headers: map[string]string{
+				HeaderAPIKey:    "signed-key",
+				HeaderTimestamp: strconv.FormatInt(now, 10),
+				HeaderSignature: sign(http.MethodPost, target, now, ""),
+			},
+			status: http.StatusUnauthorized,
+		}, {
+			name:   "other secret",
+			method: http.MethodGet,
+			headers: map[string]string{
+				HeaderAPIKey:    "signed-key",
+				HeaderTimestamp: strconv.FormatInt(now, 10),
+				HeaderSignature: hex.EncodeToString(Sign("other", http.MethodGet, target, now, nil)),
+			},
+			status: http.StatusUnauthorized,
+		}, {
+			name:    "missing signature",
+			method:  http.MethodGet,
+			headers: map[string]string{HeaderAPIKey: "signed-key", HeaderTimestamp: strconv.FormatInt(now, 10)},
+			status:  http.StatusUnauthorized,
+		}, {
+			name:   "invalid timestamp",
+			method: http.MethodGet,
+			headers: map[string]string{
+				HeaderAPIKey:    "signed-key",
+				HeaderTimestamp: "yesterday",
+				HeaderSignature: sign(http.MethodGet, target, now, ""),
+			},
+			status: http.StatusUnauthorized,
+		}, {
+			name:   "within skew",
+			method: http.MethodGet,
+			headers: map[string]string{
+				HeaderAPIKey:    "signed-key",
+				HeaderTimestamp: strconv.FormatInt(now-240, 10),
+				HeaderSignature: sign(http.MethodGet, target, now-240, ""),
+			},
+			status: http.StatusOK,
+		}, {
+			name:   "expired",
+			method: http.MethodGet,
+			headers: map[string]string{
+				HeaderAPIKey:    "signed-key",
+				HeaderTimestamp: strconv.FormatInt(now-360, 10),
+				HeaderSignature: sign(http.MethodGet, target, now-360, ""),
+			},
+			status: http.StatusUnauthorized,
+		}, {
+			name:   "from the future",
+			method: http.MethodGet,
+			headers: map[string]string{
+				HeaderAPIKey:    "signed-key",
+				HeaderTimestamp: strconv.FormatInt(now+360, 10),
+				HeaderSignature: sign(http.MethodGet, target, now+360, ""),
+			},
+			status: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body string
+			h := testAuthenticator(t).Protect(ScopeAPI, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				b, _ := ioutil.ReadAll(req.Body)
+				body = string(b)
+			}))
+
+			req := httptest.NewRequest(tt.method, target, strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if tt.status == http.StatusOK && body != tt.body {
+				t.Errorf("handler read body %q, want %q", body, tt.body)
+			}
+			if tt.status == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("WWW-Authenticate header is not set")
+			}
+		})
+	}
+}
+
+func TestProtectQuota(t *testing.T) {
+	a := testAuthenticator(t)
+	h := a.Protect(ScopeAPI, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+
+	// burst of 2, next request is allowed in hours
+	for i, status := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodGet, "/getBalance", nil)
+		req.Header.Set(HeaderAPIKey, "limited-key")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != status {
+			t.Errorf("request %d: status = %d, want %d", i, w.Code, status)
+		}
+	}
+
+	// quota is per key
+	req := httptest.NewRequest(http.MethodGet, "/getBalance", nil)
+	req.Header.Set(HeaderAPIKey, "plain-key")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("status of other key = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestNewAuthenticator(t *testing.T) {
+	tests := []struct {
+		name string
+		keys []APIKey
+		ok   bool
+	}{
+		{name: "valid", keys: []APIKey{{ID: "a", Key: "ka", Scopes: []string{ScopeAPI, ScopeHealth}}, {ID: "b", Key: "kb"}}, ok: true},
+		{name: "missing key", keys: []APIKey{{ID: "a"}}},
+		{name: "duplicate id", keys: []APIKey{{ID: "a", Key: "ka"}, {ID: "a", Key: "kb"}}},
+		{name: "duplicate key", keys: []APIKey{{ID: "a", Key: "ka"}, {ID: "b", Key: "ka"}}},
+		{name: "unknown scope", keys: []APIKey{{ID: "a", Key: "ka", Scopes: []string{"root"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewAuthenticator(tt.keys, zap.NewNop()); (err == nil) != tt.ok {
+				t.Errorf("error = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
@@ -5,32 +5,30 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/figment-networks/ethereum-worker/structures"
 	"github.com/figment-networks/indexing-engine/metrics"
 	"go.uber.org/zap"
 )
 
 var (
-	getBalanceDuration *metrics.GroupObserver
+	getBalanceDuration     *metrics.GroupObserver
+	getBalancesDuration    *metrics.GroupObserver
 	GetTotalSupplyDuration *metrics.GroupObserver
+
+	getTotalSuppliesDuration *metrics.GroupObserver
 )
 
+// maxBatchSize is the maximum number of items in a single batch request
+const maxBatchSize = 1000
+
 // GetBalance is http handler for GetBalance method
 func (c *Connector) GetBalance(w http.ResponseWriter, req *http.Request) {
 	timer := metrics.NewTimer(getBalanceDuration)
 	defer timer.ObserveDuration()
-	var (
-		intHeight uint64
-		err       error
-	)
 	enc := json.NewEncoder(w)
-	height := req.URL.Query().Get("height")
-	if height != "" {
-		intHeight, err = strconv.ParseUint(height, 10, 64)
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			enc.Encode(ServiceError{Msg: "Invalid height param: " + err.Error()})
-			return
-		}
+	bs, blk, ok := c.resolveHeight(w, req, enc)
+	if !ok {
+		return
 	}
 
 	accountAddress := req.URL.Query().Get("accountAddress")
@@ -48,13 +46,16 @@ func (c *Connector) GetBalance(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	ac, err := c.cli.GetERC20AccountBalance(req.Context(), network, contractAddress, accountAddress, intHeight)
+	ac, err := c.cli.GetAccountBalance(req.Context(), req.URL.Query().Get("chain"), network, contractAddress, accountAddress, bs)
 	if err != nil {
-		c.logger.Error("Error processing account request", zap.Error(err))
-		w.WriteHeader(http.StatusInternalServerError)
-		enc.Encode(ServiceError{Msg: "Error processing account request"})
+		c.writeClientError(w, enc, err, "Error processing account request")
 		return
 	}
+	if blk != nil { // timestamp resolution also reports block time
+		for i := range ac {
+			ac[i].Block = blk
+		}
+	}
 
 	w.WriteHeader(http.StatusOK)
 	if err = enc.Encode(ac); err != nil {
@@ -64,23 +65,55 @@ func (c *Connector) GetBalance(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// GetBalances is http handler for batch GetBalance method
+func (c *Connector) GetBalances(w http.ResponseWriter, req *http.Request) {
+	timer := metrics.NewTimer(getBalancesDuration)
+	defer timer.ObserveDuration()
+
+	enc := json.NewEncoder(w)
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		enc.Encode(ServiceError{Msg: "Method must be POST"})
+		return
+	}
+
+	reqs := []structures.BalanceRequest{}
+	if err := json.NewDecoder(req.Body).Decode(&reqs); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "Invalid request body: " + err.Error()})
+		return
+	}
+
+	if len(reqs) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "At least one request must be set"})
+		return
+	}
+
+	if len(reqs) > maxBatchSize {
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "Too many requests in batch, maximum is " + strconv.Itoa(maxBatchSize)})
+		return
+	}
+
+	res := c.cli.GetAccountBalances(req.Context(), reqs)
+
+	w.WriteHeader(http.StatusOK)
+	if err := enc.Encode(res); err != nil {
+		c.logger.Error("Error encoding response", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
 // GetTotalSupply is http handler for GetBalance method
 func (c *Connector) GetTotalSupply(w http.ResponseWriter, req *http.Request) {
 	timer := metrics.NewTimer(GetTotalSupplyDuration)
 	defer timer.ObserveDuration()
-	var (
-		intHeight uint64
-		err       error
-	)
 	enc := json.NewEncoder(w)
-	height := req.URL.Query().Get("height")
-	if height != "" {
-		intHeight, err = strconv.ParseUint(height, 10, 64)
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			enc.Encode(ServiceError{Msg: "Invalid height param: " + err.Error()})
-			return
-		}
+	bs, blk, ok := c.resolveHeight(w, req, enc)
+	if !ok {
+		return
 	}
 
 	network := req.URL.Query().Get("network")
@@ -91,13 +124,16 @@ func (c *Connector) GetTotalSupply(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	ac, err := c.cli.GetERC20TotalSupply(req.Context(), network, contractAddress, intHeight)
+	ac, err := c.cli.GetERC20TotalSupply(req.Context(), req.URL.Query().Get("chain"), network, contractAddress, bs)
 	if err != nil {
-		c.logger.Error("Error processing account request", zap.Error(err))
-		w.WriteHeader(http.StatusInternalServerError)
-		enc.Encode(ServiceError{Msg: "Error processing account request"})
+		c.writeClientError(w, enc, err, "Error processing total supply request")
 		return
 	}
+	if blk != nil { // timestamp resolution also reports block time
+		for i := range ac {
+			ac[i].Block = blk
+		}
+	}
 
 	w.WriteHeader(http.StatusOK)
 	if err = enc.Encode(ac); err != nil {
@@ -106,3 +142,44 @@ func (c *Connector) GetTotalSupply(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 }
+
+// GetTotalSupplies is http handler for batch GetTotalSupply method
+func (c *Connector) GetTotalSupplies(w http.ResponseWriter, req *http.Request) {
+	timer := metrics.NewTimer(getTotalSuppliesDuration)
+	defer timer.ObserveDuration()
+
+	enc := json.NewEncoder(w)
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		enc.Encode(ServiceError{Msg: "Method must be POST"})
+		return
+	}
+
+	reqs := []structures.TotalSupplyRequest{}
+	if err := json.NewDecoder(req.Body).Decode(&reqs); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "Invalid request body: " + err.Error()})
+		return
+	}
+
+	if len(reqs) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "At least one request must be set"})
+		return
+	}
+
+	if len(reqs) > maxBatchSize {
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "Too many requests in batch, maximum is " + strconv.Itoa(maxBatchSize)})
+		return
+	}
+
+	res := c.cli.GetERC20TotalSupplies(req.Context(), reqs)
+
+	w.WriteHeader(http.StatusOK)
+	if err := enc.Encode(res); err != nil {
+		c.logger.Error("Error encoding response", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,96 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/figment-networks/ethereum-worker/client"
+	"github.com/figment-networks/ethereum-worker/structures"
+	"go.uber.org/zap"
+)
+
+// resolveHeight reads either height or timestamp query param. Height is a block number or one of
+// latest, safe, finalized and pending tags. Timestamp is resolved on the chain selected by chain and network params
+// to the last block at or before that time, which is returned as blk. On invalid input it writes the error response and returns false.
+func (c *Connector) resolveHeight(w http.ResponseWriter, req *http.Request, enc *json.Encoder) (bs structures.BlockSelector, blk *structures.Block, ok bool) {
+	height := req.URL.Query().Get("height")
+	timestamp := req.URL.Query().Get("timestamp")
+
+	if height != "" && timestamp != "" {
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "Only one of height and timestamp params can be set"})
+		return bs, nil, false
+	}
+
+	if timestamp == "" {
+		bs, err := structures.ParseBlockSelector(height)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			enc.Encode(ServiceError{Msg: "Invalid height param: " + err.Error()})
+			return bs, nil, false
+		}
+		return bs, nil, true
+	}
+
+	ts, err := parseTimestamp(timestamp)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "Invalid timestamp param, expected RFC3339 or unix time: " + err.Error()})
+		return bs, nil, false
+	}
+
+	b, err := c.cli.GetBlockAtTimestamp(req.Context(), req.URL.Query().Get("chain"), req.URL.Query().Get("network"), ts)
+	if err != nil {
+		if errors.Is(err, client.ErrTimestampBeforeGenesis) || errors.Is(err, client.ErrTimestampInFuture) {
+			w.WriteHeader(http.StatusBadRequest)
+			enc.Encode(ServiceError{Msg: "Invalid timestamp param: " + err.Error()})
+			return bs, nil, false
+		}
+		c.writeClientError(w, enc, err, "Error resolving timestamp")
+		return bs, nil, false
+	}
+
+	return structures.NumberBlock(b.Height), &b, true
+}
+
+// writeClientError writes the response of an error returned by the client: invalid chain selection is 400,
+// height not available 422, exhausted upstream quota 429 and unavailable node 503.
+// Other errors are logged and answered with 500 and msg.
+func (c *Connector) writeClientError(w http.ResponseWriter, enc *json.Encoder, err error, msg string) {
+	switch {
+	case isChainError(err):
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: err.Error()})
+	case errors.Is(err, client.ErrHeightNotAvailable):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		enc.Encode(ServiceError{Msg: err.Error()})
+	case client.IsQuotaExhausted(err):
+		c.logger.Warn("Upstream quota exhausted", zap.Error(err))
+		w.WriteHeader(http.StatusTooManyRequests)
+		enc.Encode(ServiceError{Msg: "Upstream quota exhausted, retry later"})
+	case client.IsNodeUnavailable(err):
+		c.logger.Warn("Ethereum node unavailable", zap.Error(err))
+		w.WriteHeader(http.StatusServiceUnavailable)
+		enc.Encode(ServiceError{Msg: "Ethereum node unavailable, retry later"})
+	default:
+		c.logger.Error(msg, zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		enc.Encode(ServiceError{Msg: msg})
+	}
+}
+
+// isChainError checks if err is caused by invalid chain selection
+func isChainError(err error) bool {
+	return errors.Is(err, client.ErrUnknownChain) || errors.Is(err, client.ErrChainMismatch)
+}
+
+// parseTimestamp parses unix seconds or RFC3339 time
+func parseTimestamp(s string) (time.Time, error) {
+	if unix, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(unix, 0), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/figment-networks/ethereum-worker/api/conn"
+	"github.com/figment-networks/ethereum-worker/client"
+)
+
+func TestWriteClientError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		msg    string
+	}{
+		{name: "unknown chain", err: fmt.Errorf("%w: goerli", client.ErrUnknownChain), status: http.StatusBadRequest, msg: "unknown chain: goerli"},
+		{name: "chain mismatch", err: client.ErrChainMismatch, status: http.StatusBadRequest, msg: client.ErrChainMismatch.Error()},
+		{name: "pruned height", err: fmt.Errorf("%w: block 1", client.ErrHeightNotAvailable), status: http.StatusUnprocessableEntity},
+		{name: "quota exhausted", err: fmt.Errorf("error calling Balanceof: %w", conn.ErrQuotaExhausted), status: http.StatusTooManyRequests, msg: "Upstream quota exhausted, retry later"},
+		{name: "nodes unavailable", err: fmt.Errorf("error calling Balanceof: %w", conn.ErrCircuitOpen), status: http.StatusServiceUnavailable, msg: "Ethereum node unavailable, retry later"},
+		{name: "other error", err: errors.New("abi: cannot unmarshal"), status: http.StatusInternalServerError, msg: "Error processing request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewConnector(nil, zap.NewNop())
+			rec := httptest.NewRecorder()
+			c.writeClientError(rec, json.NewEncoder(rec), tt.err, "Error processing request")
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			var se ServiceError
+			if err := json.Unmarshal(rec.Body.Bytes(), &se); err != nil {
+				t.Fatalf("invalid response %q: %v", rec.Body.String(), err)
+			}
+			want := tt.msg
+			if want == "" {
+				want = tt.err.Error()
+			}
+			if se.Msg != want {
+				t.Errorf("error = %v, want %q", se.Msg, want)
+			}
+		})
+	}
+}
@@ -1,41 +1,63 @@
 package http
 
 import (
-	"context"
 	"fmt"
 	"net/http"
 
-	"github.com/figment-networks/ethereum-worker/structures"
+	"github.com/figment-networks/ethereum-worker/transport"
 	"go.uber.org/zap"
 )
 
-type retrieveClienter interface {
-	GetERC20AccountBalance(ctx context.Context, network, contract, address string, height uint64) ([]structures.Balance, error)
-	GetERC20TotalSupply(ctx context.Context, network, contract string, height uint64) ([]structures.Balance, error)
-}
-
 // Connector is main HTTP connector for manager
 type Connector struct {
-	cli    retrieveClienter
+	cli    transport.RetrieveClienter
 	logger *zap.Logger
+
+	admin transport.NetworkAdminer
+
+	v1      []v1Route
+	openAPI []byte
 }
 
 // NewConnector is  Connector constructor
-func NewConnector(cli retrieveClienter, logger *zap.Logger) *Connector {
+func NewConnector(cli transport.RetrieveClienter, logger *zap.Logger) *Connector {
 	getBalanceDuration = endpointDuration.WithLabels("getBalance")
+	getBalancesDuration = endpointDuration.WithLabels("getBalances")
+	getTotalSuppliesDuration = endpointDuration.WithLabels("getTotalSupplies")
 	GetTotalSupplyDuration = endpointDuration.WithLabels("getTotalSupply")
-	return &Connector{cli, logger}
+	getNFTBalanceDuration = endpointDuration.WithLabels("getNFTBalance")
+	getNFTOwnerDuration = endpointDuration.WithLabels("getNFTOwner")
+	getMultiTokenBalanceDuration = endpointDuration.WithLabels("getMultiTokenBalance")
+	networksDuration = endpointDuration.WithLabels("networks")
+	statusDuration = endpointDuration.WithLabels("status")
+	return &Connector{cli: cli, logger: logger}
 }
 
-// AttachToHandler attaches handlers to http server's mux
+// AttachToHandler attaches handlers to http server's mux, versioned REST API is served under /v1/
+// next to the legacy routes, with its OpenAPI document on /v1/openapi.json
 func (c *Connector) AttachToHandler(mux *http.ServeMux) {
 	mux.HandleFunc("/getBalance", c.GetBalance)
+	mux.HandleFunc("/getBalances", c.GetBalances)
 	mux.HandleFunc("/getTotalSupply", c.GetTotalSupply)
+	mux.HandleFunc("/getTotalSupplies", c.GetTotalSupplies)
+	mux.HandleFunc("/getNFTBalance", c.GetNFTBalance)
+	mux.HandleFunc("/getNFTOwner", c.GetNFTOwner)
+	mux.HandleFunc("/getMultiTokenBalance", c.GetMultiTokenBalance)
+	mux.HandleFunc("/status", c.Status)
+
+	c.v1 = c.v1Routes()
+	doc, err := openAPIDocument(c.v1)
+	if err != nil {
+		c.logger.Error("Error generating OpenAPI document", zap.Error(err))
+	}
+	c.openAPI = doc
+	mux.HandleFunc("/v1/", c.V1)
+	mux.HandleFunc("/v1/openapi.json", c.OpenAPI)
 }
 
-// ServiceError structure as formated error
+// ServiceError structure as formated error, status is set only by some of the handlers
 type ServiceError struct {
-	Status int         `json:"status"`
+	Status int         `json:"status,omitempty"`
 	Msg    interface{} `json:"error"`
 }
 
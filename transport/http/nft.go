@@ -0,0 +1,188 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/figment-networks/ethereum-worker/client"
+	"github.com/figment-networks/ethereum-worker/structures"
+	"github.com/figment-networks/indexing-engine/metrics"
+	"go.uber.org/zap"
+)
+
+var (
+	getNFTBalanceDuration *metrics.GroupObserver
+	getNFTOwnerDuration   *metrics.GroupObserver
+
+	getMultiTokenBalanceDuration *metrics.GroupObserver
+)
+
+// GetNFTBalance is http handler for GetNFTBalance method
+func (c *Connector) GetNFTBalance(w http.ResponseWriter, req *http.Request) {
+	timer := metrics.NewTimer(getNFTBalanceDuration)
+	defer timer.ObserveDuration()
+	enc := json.NewEncoder(w)
+	bs, err := structures.ParseBlockSelector(req.URL.Query().Get("height"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "Invalid height param: " + err.Error()})
+		return
+	}
+
+	accountAddress := req.URL.Query().Get("accountAddress")
+	if accountAddress == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "AccountAddress must be set"})
+		return
+	}
+
+	contractAddress := req.URL.Query().Get("contractAddress")
+	if contractAddress == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "ContractAddress must be set"})
+		return
+	}
+
+	ac, err := c.cli.GetERC721AccountBalance(req.Context(), req.URL.Query().Get("chain"), contractAddress, accountAddress, bs)
+	if err != nil {
+		c.writeNFTError(w, enc, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err = enc.Encode(ac); err != nil {
+		c.logger.Error("Error encoding response", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetNFTOwner is http handler for GetNFTOwner method
+func (c *Connector) GetNFTOwner(w http.ResponseWriter, req *http.Request) {
+	timer := metrics.NewTimer(getNFTOwnerDuration)
+	defer timer.ObserveDuration()
+	enc := json.NewEncoder(w)
+	bs, err := structures.ParseBlockSelector(req.URL.Query().Get("height"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "Invalid height param: " + err.Error()})
+		return
+	}
+
+	contractAddress := req.URL.Query().Get("contractAddress")
+	if contractAddress == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "ContractAddress must be set"})
+		return
+	}
+
+	tokenID, ok := new(big.Int).SetString(req.URL.Query().Get("tokenId"), 0)
+	if !ok || tokenID.Sign() < 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "TokenId must be set to a non negative integer"})
+		return
+	}
+
+	o, err := c.cli.GetERC721Owner(req.Context(), req.URL.Query().Get("chain"), contractAddress, tokenID, bs)
+	if err != nil {
+		c.writeNFTError(w, enc, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err = enc.Encode(o); err != nil {
+		c.logger.Error("Error encoding response", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetMultiTokenBalance is http handler for GetMultiTokenBalance method
+func (c *Connector) GetMultiTokenBalance(w http.ResponseWriter, req *http.Request) {
+	timer := metrics.NewTimer(getMultiTokenBalanceDuration)
+	defer timer.ObserveDuration()
+	enc := json.NewEncoder(w)
+	bs, err := structures.ParseBlockSelector(req.URL.Query().Get("height"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "Invalid height param: " + err.Error()})
+		return
+	}
+
+	accountAddress := req.URL.Query().Get("accountAddress")
+	if accountAddress == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "AccountAddress must be set"})
+		return
+	}
+
+	contractAddress := req.URL.Query().Get("contractAddress")
+	if contractAddress == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "ContractAddress must be set"})
+		return
+	}
+
+	tokenIds := req.URL.Query().Get("tokenIds")
+	if tokenIds == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "TokenIds must be set"})
+		return
+	}
+
+	idsStr := strings.Split(tokenIds, ",")
+	if len(idsStr) > maxBatchSize {
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "Too many token ids, maximum is " + strconv.Itoa(maxBatchSize)})
+		return
+	}
+
+	ids := make([]*big.Int, len(idsStr))
+	for i, idStr := range idsStr {
+		id, ok := new(big.Int).SetString(strings.TrimSpace(idStr), 0)
+		if !ok || id.Sign() < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			enc.Encode(ServiceError{Msg: "Invalid token id: " + idStr})
+			return
+		}
+		ids[i] = id
+	}
+
+	withURI, _ := strconv.ParseBool(req.URL.Query().Get("withUri"))
+
+	ac, err := c.cli.GetERC1155AccountBalances(req.Context(), req.URL.Query().Get("chain"), contractAddress, accountAddress, ids, withURI, bs)
+	if err != nil {
+		c.writeNFTError(w, enc, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if err = enc.Encode(ac); err != nil {
+		c.logger.Error("Error encoding response", zap.Error(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+func (c *Connector) writeNFTError(w http.ResponseWriter, enc *json.Encoder, err error) {
+	switch {
+	case errors.Is(err, client.ErrNotERC721):
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "Contract is not ERC721"})
+	case errors.Is(err, client.ErrNotERC1155):
+		w.WriteHeader(http.StatusBadRequest)
+		enc.Encode(ServiceError{Msg: "Contract is not ERC1155"})
+	case errors.Is(err, client.ErrTokenNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		enc.Encode(ServiceError{Msg: "Token does not exist"})
+	case errors.Is(err, client.ErrERC721NotEnabled), errors.Is(err, client.ErrERC1155NotEnabled):
+		w.WriteHeader(http.StatusNotImplemented)
+		enc.Encode(ServiceError{Msg: err.Error()})
+	default:
+		c.writeClientError(w, enc, err, "Error processing nft request")
+	}
+}
@@ -0,0 +1,232 @@
+package http
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+// OpenAPIVersion is the version of API described by OpenAPI document
+const OpenAPIVersion = "1.0.0"
+
+var (
+	bigIntType        = reflect.TypeOf(big.Int{})
+	timeType          = reflect.TypeOf(time.Time{})
+	blockSelectorType = reflect.TypeOf(structures.BlockSelector{})
+)
+
+// OpenAPI is http handler serving OpenAPI 3 document of versioned REST API
+func (c *Connector) OpenAPI(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(c.openAPI); err != nil {
+		c.logger.Error("Error writing response", zap.Error(err))
+	}
+}
+
+// openAPIDocument generates OpenAPI document of the routes, schemas are read from the types of their bodies and responses
+func openAPIDocument(routes []v1Route) ([]byte, error) {
+	sg := &schemaGenerator{schemas: map[string]interface{}{}}
+	errorRef := sg.schema(reflect.TypeOf(ServiceError{}))
+
+	paths := map[string]map[string]interface{}{}
+	for _, r := range routes {
+		op := map[string]interface{}{
+			"operationId": r.operationID,
+			"summary":     r.summary,
+		}
+
+		params := []interface{}{}
+		for _, s := range strings.Split(r.path, "/") {
+			if strings.HasPrefix(s, "{") {
+				name := strings.Trim(s, "{}")
+				params = append(params, map[string]interface{}{
+					"name": name, "in": "path", "required": true,
+					"description": pathParamDocs[name],
+					"schema":      map[string]interface{}{"type": "string"},
+				})
+			}
+		}
+		for _, p := range r.params {
+			params = append(params, map[string]interface{}{
+				"name": p.name, "in": "query", "required": p.required,
+				"description": p.desc,
+				"schema":      map[string]interface{}{"type": p.typ},
+			})
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+
+		if r.body != nil {
+			sg.request = true
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  jsonContent(sg.schema(reflect.TypeOf(r.body))),
+			}
+			sg.request = false
+		}
+
+		responses := map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "OK",
+				"content":     jsonContent(sg.schema(reflect.TypeOf(r.response))),
+			},
+		}
+		for _, code := range r.errors {
+			responses[strconv.Itoa(code)] = map[string]interface{}{
+				"description": http.StatusText(code),
+				"content":     jsonContent(errorRef),
+			}
+		}
+		op["responses"] = responses
+
+		if paths[r.path] == nil {
+			paths[r.path] = map[string]interface{}{}
+		}
+		paths[r.path][strings.ToLower(r.method)] = op
+	}
+
+	paths["/v1/openapi.json"] = map[string]interface{}{
+		"get": map[string]interface{}{
+			"operationId": "getOpenAPI",
+			"summary":     "Returns this document",
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{"description": "OpenAPI 3 document"},
+			},
+		},
+	}
+
+	return json.Marshal(map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "ethereum-worker",
+			"version": OpenAPIVersion,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": sg.schemas,
+			"securitySchemes": map[string]interface{}{
+				"apiKey": map[string]interface{}{"type": "apiKey", "in": "header", "name": HeaderAPIKey},
+				"bearer": map[string]interface{}{"type": "http", "scheme": "bearer", "description": "Api key as bearer token"},
+				"timestamp": map[string]interface{}{
+					"type": "apiKey", "in": "header", "name": HeaderTimestamp,
+					"description": "Unix time of signed request, it may differ from the server time by the configured skew at most",
+				},
+				"signature": map[string]interface{}{
+					"type": "apiKey", "in": "header", "name": HeaderSignature,
+					"description": "Hex encoded HMAC-SHA256 of METHOD\\nREQUEST_URI\\nTIMESTAMP\\n followed by the request body, with the secret of the api key",
+				},
+			},
+		},
+		// authentication is optional, it depends on the worker configuration. Keys with secret have to sign requests.
+		"security": []interface{}{
+			map[string]interface{}{},
+			map[string]interface{}{"apiKey": []string{}},
+			map[string]interface{}{"bearer": []string{}},
+			map[string]interface{}{"apiKey": []string{}, "timestamp": []string{}, "signature": []string{}},
+			map[string]interface{}{"bearer": []string{}, "timestamp": []string{}, "signature": []string{}},
+		},
+	})
+}
+
+func jsonContent(schema interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{"schema": schema},
+	}
+}
+
+// schemaGenerator generates JSON schemas of types, structs are added to schemas and referenced by name.
+// Response fields are required unless they are omitted when empty, request fields are all optional.
+type schemaGenerator struct {
+	schemas map[string]interface{}
+	request bool
+}
+
+func (sg *schemaGenerator) schema(t reflect.Type) map[string]interface{} {
+	switch t {
+	case bigIntType:
+		return map[string]interface{}{
+			"type": "integer", "format": "bigint",
+			"description": "Arbitrary precision integer, a JSON number that may not fit in 64 bits or a double. Decode it as a big integer or from the raw number text.",
+		}
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case blockSelectorType:
+		return map[string]interface{}{
+			"oneOf": []interface{}{
+				map[string]interface{}{"type": "integer", "format": "uint64"},
+				map[string]interface{}{"type": "string", "enum": []string{"latest", "safe", "finalized", "pending"}},
+			},
+			"description": "Block number or tag",
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := sg.schema(t.Elem())
+		if _, ok := s["$ref"]; ok { // siblings of $ref are ignored
+			return map[string]interface{}{"allOf": []interface{}{s}, "nullable": true}
+		}
+		s["nullable"] = true
+		return s
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": sg.schema(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "uint64", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Interface: // ServiceError message
+		return map[string]interface{}{"type": "string"}
+	case reflect.Struct:
+		return sg.structSchema(t)
+	}
+	return map[string]interface{}{}
+}
+
+func (sg *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	ref := map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	if _, ok := sg.schemas[t.Name()]; ok {
+		return ref
+	}
+	sg.schemas[t.Name()] = nil // recursive types reference the schema being generated
+
+	props := map[string]interface{}{}
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if f.PkgPath != "" || tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = sg.schema(f.Type)
+		if !sg.request && !strings.Contains(tag, ",omitempty") && f.Type.Kind() != reflect.Ptr {
+			required = append(required, name)
+		}
+	}
+
+	s := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	sg.schemas[t.Name()] = s
+	return ref
+}
@@ -0,0 +1,210 @@
+package http
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+func TestOpenAPIDocument(t *testing.T) {
+	c := NewConnector(nil, zap.NewNop())
+	mux := http.NewServeMux()
+	c.AttachToHandler(mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var doc struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas         map[string]map[string]interface{} `json:"schemas"`
+			SecuritySchemes map[string]map[string]interface{} `json:"securitySchemes"`
+		} `json:"components"`
+		Security []map[string][]string `json:"security"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+	if doc.OpenAPI != "3.0.3" {
+		t.Errorf("openapi = %s, want 3.0.3", doc.OpenAPI)
+	}
+
+	t.Run("routes", func(t *testing.T) {
+		for _, r := range c.v1Routes() {
+			op, ok := doc.Paths[r.path][strings.ToLower(r.method)]
+			if !ok {
+				t.Errorf("%s %s is not documented", r.method, r.path)
+				continue
+			}
+			if op["operationId"] != r.operationID {
+				t.Errorf("%s %s operationId = %v, want %s", r.method, r.path, op["operationId"], r.operationID)
+			}
+		}
+	})
+
+	t.Run("references", func(t *testing.T) {
+		var refs []string
+		collectRefs(w.Body.Bytes(), &refs)
+		if len(refs) == 0 {
+			t.Fatal("no schema references")
+		}
+		for _, ref := range refs {
+			name := strings.TrimPrefix(ref, "#/components/schemas/")
+			if doc.Components.Schemas[name] == nil {
+				t.Errorf("reference %s is not defined", ref)
+			}
+		}
+	})
+
+	t.Run("error schema", func(t *testing.T) {
+		s := doc.Components.Schemas["ServiceError"]
+		required, _ := s["required"].([]interface{})
+		if !reflect.DeepEqual(required, []interface{}{"error"}) {
+			t.Errorf("required = %v, want only error", required)
+		}
+	})
+
+	t.Run("security", func(t *testing.T) {
+		headers := map[string]bool{}
+		for _, s := range doc.Components.SecuritySchemes {
+			if s["in"] == "header" {
+				headers[s["name"].(string)] = true
+			}
+		}
+		for _, h := range []string{HeaderAPIKey, HeaderTimestamp, HeaderSignature} {
+			if !headers[h] {
+				t.Errorf("header %s is not in security schemes", h)
+			}
+		}
+		for _, req := range doc.Security {
+			for name := range req {
+				if doc.Components.SecuritySchemes[name] == nil {
+					t.Errorf("security requirement %s is not defined", name)
+				}
+			}
+		}
+	})
+}
+
+// collectRefs appends all the $ref values of JSON document
+func collectRefs(data []byte, refs *[]string) {
+	var v interface{}
+	json.Unmarshal(data, &v)
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, e := range v {
+				if s, ok := e.(string); ok && k == "$ref" {
+					*refs = append(*refs, s)
+				}
+				walk(e)
+			}
+		case []interface{}:
+			for _, e := range v {
+				walk(e)
+			}
+		}
+	}
+	walk(v)
+}
+
+type schemaTest struct {
+	Name     string            `json:"name"`
+	Optional string            `json:"optional,omitempty"`
+	Pointer  *uint64           `json:"pointer"`
+	Ignored  string            `json:"-"`
+	Nested   []schemaTestChild `json:"nested"`
+	hidden   string
+}
+
+type schemaTestChild struct {
+	Value big.Int `json:"value"`
+}
+
+func TestSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		typ     interface{}
+		request bool
+		want    string
+		schemas map[string]string
+	}{
+		{
+			name: "big int",
+			typ:  big.Int{},
+			want: `{"description":"Arbitrary precision integer, a JSON number that may not fit in 64 bits or a double. Decode it as a big integer or from the raw number text.","format":"bigint","type":"integer"}`,
+		}, {
+			name: "time",
+			typ:  time.Time{},
+			want: `{"format":"date-time","type":"string"}`,
+		}, {
+			name: "block selector",
+			typ:  structures.BlockSelector{},
+			want: `{"description":"Block number or tag","oneOf":[{"format":"uint64","type":"integer"},{"enum":["latest","safe","finalized","pending"],"type":"string"}]}`,
+		}, {
+			name: "pointer",
+			typ:  new(string),
+			want: `{"nullable":true,"type":"string"}`,
+		}, {
+			name: "slice",
+			typ:  []uint64{},
+			want: `{"items":{"format":"uint64","minimum":0,"type":"integer"},"type":"array"}`,
+		}, {
+			name: "response struct",
+			typ:  schemaTest{},
+			want: `{"$ref":"#/components/schemas/schemaTest"}`,
+			schemas: map[string]string{
+				"schemaTest":      `{"properties":{"name":{"type":"string"},"nested":{"items":{"$ref":"#/components/schemas/schemaTestChild"},"type":"array"},"optional":{"type":"string"},"pointer":{"format":"uint64","minimum":0,"nullable":true,"type":"integer"}},"required":["name","nested"],"type":"object"}`,
+				"schemaTestChild": `{"properties":{"value":{"description":"Arbitrary precision integer, a JSON number that may not fit in 64 bits or a double. Decode it as a big integer or from the raw number text.","format":"bigint","type":"integer"}},"required":["value"],"type":"object"}`,
+			},
+		}, {
+			name:    "request struct",
+			typ:     schemaTestChild{},
+			request: true,
+			want:    `{"$ref":"#/components/schemas/schemaTestChild"}`,
+			schemas: map[string]string{
+				"schemaTestChild": `{"properties":{"value":{"description":"Arbitrary precision integer, a JSON number that may not fit in 64 bits or a double. Decode it as a big integer or from the raw number text.","format":"bigint","type":"integer"}},"type":"object"}`,
+			},
+		}, {
+			name: "pointer to struct",
+			typ:  &schemaTestChild{},
+			want: `{"allOf":[{"$ref":"#/components/schemas/schemaTestChild"}],"nullable":true}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sg := &schemaGenerator{schemas: map[string]interface{}{}, request: tt.request}
+			if got := marshal(t, sg.schema(reflect.TypeOf(tt.typ))); got != tt.want {
+				t.Errorf("schema = %s, want %s", got, tt.want)
+			}
+			for name, want := range tt.schemas {
+				if got := marshal(t, sg.schemas[name]); got != want {
+					t.Errorf("schema %s = %s, want %s", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func marshal(t *testing.T, v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,23 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/figment-networks/indexing-engine/metrics"
+	"go.uber.org/zap"
+)
+
+var statusDuration *metrics.GroupObserver
+
+// Status is http handler listing served chains with the identity verified on their nodes
+func (c *Connector) Status(w http.ResponseWriter, req *http.Request) {
+	timer := metrics.NewTimer(statusDuration)
+	defer timer.ObserveDuration()
+
+	enc := json.NewEncoder(w)
+	w.WriteHeader(http.StatusOK)
+	if err := enc.Encode(c.cli.ChainStatus()); err != nil {
+		c.logger.Error("Error encoding response", zap.Error(err))
+	}
+}
@@ -0,0 +1,218 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+// v1Route is a route of versioned REST API. Its handler is the legacy handler reading
+// the same params from query, path params are mapped to them by query.
+type v1Route struct {
+	method  string
+	path    string
+	handler http.HandlerFunc
+	query   func(p map[string]string, q url.Values)
+
+	operationID string
+	summary     string
+	params      []apiParam
+	body        interface{}
+	response    interface{}
+	errors      []int
+}
+
+// apiParam is a documented query param
+type apiParam struct {
+	name     string
+	desc     string
+	typ      string
+	required bool
+}
+
+var (
+	heightParam    = apiParam{name: "height", desc: "Block number or one of latest (default), safe, finalized and pending tags", typ: "string"}
+	timestampParam = apiParam{name: "timestamp", desc: "RFC3339 or unix time, the last block at or before it is read. Exclusive with height.", typ: "string"}
+)
+
+// pathParamDocs describe path params of all the routes
+var pathParamDocs = map[string]string{
+	"chain":    "Chain id or name",
+	"token":    "Token contract address or network name",
+	"contract": "Token contract address",
+	"account":  "Account address",
+	"tokenId":  "Token id, decimal or 0x prefixed hex",
+}
+
+// v1Routes returns routes of versioned REST API
+func (c *Connector) v1Routes() []v1Route {
+	commonErrors := []int{http.StatusBadRequest, http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable}
+	return []v1Route{{
+		method:      http.MethodGet,
+		path:        "/v1/chains",
+		handler:     c.Status,
+		operationID: "listChains",
+		summary:     "Lists served chains with the identity verified on their nodes",
+		response:    []structures.ChainStatus{},
+	}, {
+		method:      http.MethodGet,
+		path:        "/v1/chains/{chain}/tokens/{token}/balances/{account}",
+		handler:     c.GetBalance,
+		query:       tokenQuery("accountAddress"),
+		operationID: "getBalance",
+		summary:     "Returns token balance of the account, native currency balance for native networks",
+		params:      []apiParam{heightParam, timestampParam},
+		response:    []structures.Balance{},
+		errors:      append(commonErrors, http.StatusUnprocessableEntity),
+	}, {
+		method:      http.MethodGet,
+		path:        "/v1/chains/{chain}/tokens/{token}/supply",
+		handler:     c.GetTotalSupply,
+		query:       tokenQuery(""),
+		operationID: "getTotalSupply",
+		summary:     "Returns token total supply",
+		params:      []apiParam{heightParam, timestampParam},
+		response:    []structures.Balance{},
+		errors:      append(commonErrors, http.StatusUnprocessableEntity),
+	}, {
+		method:      http.MethodGet,
+		path:        "/v1/chains/{chain}/nfts/{contract}/balances/{account}",
+		handler:     c.GetNFTBalance,
+		query:       renameQuery(map[string]string{"contract": "contractAddress", "account": "accountAddress"}),
+		operationID: "getNFTBalance",
+		summary:     "Returns the number of ERC721 tokens the account owns",
+		params:      []apiParam{heightParam},
+		response:    []structures.Balance{},
+		errors:      append(commonErrors, http.StatusUnprocessableEntity, http.StatusNotImplemented),
+	}, {
+		method:      http.MethodGet,
+		path:        "/v1/chains/{chain}/nfts/{contract}/tokens/{tokenId}/owner",
+		handler:     c.GetNFTOwner,
+		query:       renameQuery(map[string]string{"contract": "contractAddress", "tokenId": "tokenId"}),
+		operationID: "getNFTOwner",
+		summary:     "Returns the owner of ERC721 token",
+		params:      []apiParam{heightParam},
+		response:    structures.NFTOwner{},
+		errors:      append(commonErrors, http.StatusNotFound, http.StatusUnprocessableEntity, http.StatusNotImplemented),
+	}, {
+		method:      http.MethodGet,
+		path:        "/v1/chains/{chain}/multi-tokens/{contract}/balances/{account}",
+		handler:     c.GetMultiTokenBalance,
+		query:       renameQuery(map[string]string{"contract": "contractAddress", "account": "accountAddress"}),
+		operationID: "getMultiTokenBalance",
+		summary:     "Returns ERC1155 token balances of the account",
+		params: []apiParam{
+			{name: "tokenIds", desc: "Comma separated token ids", typ: "string", required: true},
+			{name: "withUri", desc: "Reads token URIs as well", typ: "boolean"},
+			heightParam,
+		},
+		response: []structures.Balance{},
+		errors:   append(commonErrors, http.StatusUnprocessableEntity, http.StatusNotImplemented),
+	}, {
+		method:      http.MethodPost,
+		path:        "/v1/balances",
+		handler:     c.GetBalances,
+		operationID: "getBalances",
+		summary:     "Returns balances of all the requests, failed requests report their error",
+		body:        []structures.BalanceRequest{},
+		response:    []structures.BalanceResult{},
+		errors:      []int{http.StatusBadRequest},
+	}, {
+		method:      http.MethodPost,
+		path:        "/v1/supplies",
+		handler:     c.GetTotalSupplies,
+		operationID: "getTotalSupplies",
+		summary:     "Returns total supplies of all the requests, failed requests report their error",
+		body:        []structures.TotalSupplyRequest{},
+		response:    []structures.BalanceResult{},
+		errors:      []int{http.StatusBadRequest},
+	}}
+}
+
+// tokenQuery maps token param to contract address or network name, account param to accountParam when set
+func tokenQuery(accountParam string) func(p map[string]string, q url.Values) {
+	return func(p map[string]string, q url.Values) {
+		if common.IsHexAddress(p["token"]) {
+			q.Set("contractAddress", p["token"])
+		} else {
+			q.Set("network", p["token"])
+		}
+		if accountParam != "" {
+			q.Set(accountParam, p["account"])
+		}
+	}
+}
+
+// renameQuery maps path params to query params of given names
+func renameQuery(names map[string]string) func(p map[string]string, q url.Values) {
+	return func(p map[string]string, q url.Values) {
+		for param, name := range names {
+			q.Set(name, p[param])
+		}
+	}
+}
+
+// match returns path params when path matches the route
+func (r v1Route) match(segments []string) (map[string]string, bool) {
+	pattern := strings.Split(strings.Trim(r.path, "/"), "/")
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+
+	p := map[string]string{}
+	for i, s := range pattern {
+		if strings.HasPrefix(s, "{") {
+			if segments[i] == "" {
+				return nil, false
+			}
+			p[strings.Trim(s, "{}")] = segments[i]
+			continue
+		}
+		if s != segments[i] {
+			return nil, false
+		}
+	}
+	return p, true
+}
+
+// V1 is http handler routing versioned REST API
+func (c *Connector) V1(w http.ResponseWriter, req *http.Request) {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+
+	var methodMismatch bool
+	for _, r := range c.v1 {
+		p, ok := r.match(segments)
+		if !ok {
+			continue
+		}
+		if req.Method != r.method {
+			methodMismatch = true
+			continue
+		}
+
+		q := req.URL.Query()
+		if chain, ok := p["chain"]; ok {
+			q.Set("chain", chain)
+		}
+		if r.query != nil {
+			r.query(p, q)
+		}
+		req.URL.RawQuery = q.Encode()
+		r.handler(w, req)
+		return
+	}
+
+	enc := json.NewEncoder(w)
+	if methodMismatch {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		enc.Encode(ServiceError{Status: http.StatusMethodNotAllowed, Msg: "Method not allowed"})
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+	enc.Encode(ServiceError{Status: http.StatusNotFound, Msg: "Not found"})
+}
@@ -0,0 +1,31 @@
+package transport
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/figment-networks/ethereum-worker/structures"
+)
+
+// RetrieveClienter is the client interface shared by all the connectors.
+// Chain is selected by its id or name, empty chain selects network's chain or the default one.
+type RetrieveClienter interface {
+	GetAccountBalance(ctx context.Context, chain, network, contract, address string, bs structures.BlockSelector) ([]structures.Balance, error)
+	GetAccountBalances(ctx context.Context, reqs []structures.BalanceRequest) []structures.BalanceResult
+	GetERC20TotalSupply(ctx context.Context, chain, network, contract string, bs structures.BlockSelector) ([]structures.Balance, error)
+	GetERC20TotalSupplies(ctx context.Context, reqs []structures.TotalSupplyRequest) []structures.BalanceResult
+	GetERC721AccountBalance(ctx context.Context, chain, contract, address string, bs structures.BlockSelector) ([]structures.Balance, error)
+	GetERC721Owner(ctx context.Context, chain, contract string, tokenID *big.Int, bs structures.BlockSelector) (structures.NFTOwner, error)
+	GetBlockAtTimestamp(ctx context.Context, chain, network string, ts time.Time) (structures.Block, error)
+	GetERC1155AccountBalances(ctx context.Context, chain, contract, address string, ids []*big.Int, withURI bool, bs structures.BlockSelector) ([]structures.Balance, error)
+	ChainStatus() []structures.ChainStatus
+}
+
+// NetworkAdminer is the client interface of the network registry administration
+type NetworkAdminer interface {
+	ListNetworks() []structures.Network
+	AddNetwork(ctx context.Context, n structures.Network) (structures.Network, error)
+	UpdateNetwork(ctx context.Context, n structures.Network) (structures.Network, error)
+	RemoveNetwork(name string) error
+}